@@ -1,24 +1,52 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"maps"
 	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/adrg/xdg"
 	"gopkg.in/ini.v1"
 )
 
+// the profile that is used when no other profile is selected
+const DefaultProfile = "obs-mcu"
+
 var configFilePath string
+var profileName = DefaultProfile
+
+// guards the config file path, the profile can be switched while the
+// config file is watched
+var pathLock sync.Mutex
 var cfg *ini.File
 
+// '#' and ';' are part of the values, e.g. colors in the json of a request,
+// comments have to be on their own line
+var loadOptions = ini.LoadOptions{IgnoreInlineComment: true}
+
 type IniFile struct {
 	*General
 	*Midi
 	*Advanced
 	*McuFaders
 	*McuVpots
+	*McuJog
+	*McuDisplay
+	*McuChannels
 	*McuLeds
 	*McuButtons
+	// commands and led states of all switches by their config key
+	ButtonMap map[string]string `ini:"-"`
+	LedMap    map[string]string `ini:"-"`
 }
 
 type General struct {
@@ -27,12 +55,16 @@ type General struct {
 }
 
 type Midi struct {
-	PortIn  string
-	PortOut string
+	PortIn           string
+	PortOut          string
+	ExtenderPortsIn  []string
+	ExtenderPortsOut []string
 }
 
 type Advanced struct {
-	SyncDelay int
+	SyncDelay       int
+	HoldTime        int
+	DoublePressTime int
 }
 
 type McuFaders struct {
@@ -46,7 +78,7 @@ type McuFaders struct {
 	//Fader6      string
 	//Fader7      string
 	//Fader8      string
-	//FaderMaster string
+	FaderMaster string
 }
 
 type McuVpots struct {
@@ -60,6 +92,30 @@ type McuVpots struct {
 	//Vpot8 string
 }
 
+type McuJog struct {
+	Mode       string
+	VolumeStep float64
+	MediaStep  int
+}
+
+type McuDisplay struct {
+	Timecode string
+}
+
+// names in the channel rules are matched exactly,
+// names enclosed in slashes are regular expressions
+type McuChannels struct {
+	Visibility      string
+	VisibilityScene string
+	Include         []string
+	Exclude         []string
+	ExcludeKinds    []string
+	Order           []string
+	Pinned          []string
+}
+
+// the fields of McuLeds and McuButtons are written to the config file
+// by default, all other switches can be mapped using their config key
 type McuLeds struct {
 	//Rec1             string
 	//Rec2             string
@@ -239,265 +295,468 @@ type McuButtons struct {
 	//FaderMaster      string
 }
 
-var Config = IniFile{
-	&General{
-		ObsHost:     "localhost:4455",
-		ObsPassword: "",
-	},
-	&Midi{
-		PortIn:  "",
-		PortOut: "",
-	},
-	&Advanced{
-		SyncDelay: 100,
-	},
-	&McuFaders{
-		ShowMeters:    false,
-		SimulateTouch: false,
-		//Fader1:      "",
-		//Fader2:      "",
-		//Fader3:      "",
-		//Fader4:      "",
-		//Fader5:      "",
-		//Fader6:      "",
-		//Fader7:      "",
-		//Fader8:      "",
-		//FaderMaster: "",
-	},
-	&McuVpots{
-		//Vpot1: "",
-		//Vpot2: "",
-		//Vpot3: "",
-		//Vpot4: "",
-		//Vpot5: "",
-		//Vpot6: "",
-		//Vpot7: "",
-		//Vpot8: "",
-	},
-	&McuLeds{
-		//Rec1:             "",
-		//Rec2:             "",
-		//Rec3:             "",
-		//Rec4:             "",
-		//Rec5:             "",
-		//Rec6:             "",
-		//Rec7:             "",
-		//Rec8:             "",
-		//Solo1:            "",
-		//Solo2:            "",
-		//Solo3:            "",
-		//Solo4:            "",
-		//Solo5:            "",
-		//Solo6:            "",
-		//Solo7:            "",
-		//Solo8:            "",
-		//Mute1:            "",
-		//Mute2:            "",
-		//Mute3:            "",
-		//Mute4:            "",
-		//Mute5:            "",
-		//Mute6:            "",
-		//Mute7:            "",
-		//Mute8:            "",
-		//Select1:          "",
-		//Select2:          "",
-		//Select3:          "",
-		//Select4:          "",
-		//Select5:          "",
-		//Select6:          "",
-		//Select7:          "",
-		//Select8:          "",
-		//AssignTrack:      "",
-		//AssignSend:       "",
-		//AssignPan:        "",
-		//AssignPlugin:     "",
-		//AssignEQ:         "",
-		//AssignInstrument: "",
-		//Read:    "",
-		//Write:   "",
-		//Trim:    "",
-		//Touch:   "",
-		//Latch:   "",
-		//Group:   "",
-		Save:    "",
-		Undo:    "",
-		Marker:  "",
-		Nudge:   "",
-		Cycle:   "",
-		Drop:    "",
-		Replace: "",
-		Click:   "",
-		Solo:    "",
-		Rewind:  "",
-		FastFwd: "",
-		Stop:    "",
-		Play:    "STATE:StreamState",
-		Record:  "STATE:RecordState",
-		Zoom:    "",
-		Scrub:   "",
-	},
-	&McuButtons{
-		//Rec1:             "",
-		//Rec2:             "",
-		//Rec3:             "",
-		//Rec4:             "",
-		//Rec5:             "",
-		//Rec6:             "",
-		//Rec7:             "",
-		//Rec8:             "",
-		//Solo1:            "",
-		//Solo2:            "",
-		//Solo3:            "",
-		//Solo4:            "",
-		//Solo5:            "",
-		//Solo6:            "",
-		//Solo7:            "",
-		//Solo8:            "",
-		//Mute1:            "",
-		//Mute2:            "",
-		//Mute3:            "",
-		//Mute4:            "",
-		//Mute5:            "",
-		//Mute6:            "",
-		//Mute7:            "",
-		//Mute8:            "",
-		//Select1:          "",
-		//Select2:          "",
-		//Select3:          "",
-		//Select4:          "",
-		//Select5:          "",
-		//Select6:          "",
-		//Select7:          "",
-		//Select8:          "",
-		//V1:               "",
-		//V2:               "",
-		//V3:               "",
-		//V4:               "",
-		//V5:               "",
-		//V6:               "",
-		//V7:               "",
-		//V8:               "",
-		//AssignTrack:      "",
-		//AssignSend:       "",
-		//AssignPan:        "",
-		//AssignPlugin:     "",
-		//AssignEQ:         "",
-		//AssignInstrument: "",
-		//BankL:           "",
-		//BankR:           "",
-		//ChannelL:        "",
-		//ChannelR:        "",
-		//Flip:            "",
-		//GlobalView:      "",
-		//NameValue:       "",
-		//SMPTEBeats:      "",
-		F1:              "",
-		F2:              "",
-		F3:              "",
-		F4:              "",
-		F5:              "",
-		F6:              "",
-		F7:              "",
-		F8:              "",
-		MIDITracks:      "",
-		Inputs:          "",
-		AudioTracks:     "",
-		AudioInstrument: "",
-		Aux:             "",
-		Busses:          "",
-		Outputs:         "",
-		User:            "",
-		Shift:           "",
-		Option:          "",
-		Control:         "",
-		CMDAlt:          "",
-		//Read:            "",
-		//Write:           "",
-		//Trim:            "",
-		//Touch:           "",
-		//Latch:           "",
-		//Group:           "",
-		Save:    "",
-		Undo:    "",
-		Cancel:  "",
-		Enter:   "",
-		Marker:  "",
-		Nudge:   "",
-		Cycle:   "",
-		Drop:    "",
-		Replace: "",
-		Click:   "",
-		Solo:    "",
-		Rewind:  "",
-		FastFwd: "",
-		Stop:    "KEY:OBSBasic.ForceStopStreaming,OBSBasic.StopRecording",
-		Play:    "KEY:OBSBasic.StartStreaming",
-		Record:  "KEY:OBSBasic.StartRecording",
-		Up:      "",
-		Down:    "",
-		Left:    "",
-		Right:   "",
-		Zoom:    "",
-		Scrub:   "",
-		UserA:   "",
-		UserB:   "",
-		//Fader1:           "",
-		//Fader2:           "",
-		//Fader3:           "",
-		//Fader4:           "",
-		//Fader5:           "",
-		//Fader6:           "",
-		//Fader7:           "",
-		//Fader8:           "",
-		//FaderMaster:      "",
-	},
+// the current config, the watcher replaces it while the runloops
+// and the midi callback read it, so it is only accessed through Get and Update
+var current atomic.Pointer[IniFile]
+
+func init() {
+	config := defaultConfig()
+	current.Store(&config)
+}
+
+// Get returns the current config, it must not be changed, use Update for that
+func Get() *IniFile {
+	return current.Load()
+}
+
+// Update changes a copy of the current config, replaces the
+// current config with it and saves it to the config file
+func Update(change func(config *IniFile)) error {
+	config := Get().clone()
+	change(config)
+	current.Store(config)
+	return SaveConfig()
+}
+
+// returns a copy of the config with copies of all sections and maps
+func (c *IniFile) clone() *IniFile {
+	config := *c
+	general := *c.General
+	midi := *c.Midi
+	advanced := *c.Advanced
+	faders := *c.McuFaders
+	vpots := *c.McuVpots
+	jog := *c.McuJog
+	display := *c.McuDisplay
+	channels := *c.McuChannels
+	leds := *c.McuLeds
+	buttons := *c.McuButtons
+	config.General = &general
+	config.Midi = &midi
+	config.Advanced = &advanced
+	config.McuFaders = &faders
+	config.McuVpots = &vpots
+	config.McuJog = &jog
+	config.McuDisplay = &display
+	config.McuChannels = &channels
+	config.McuLeds = &leds
+	config.McuButtons = &buttons
+	config.ButtonMap = maps.Clone(c.ButtonMap)
+	config.LedMap = maps.Clone(c.LedMap)
+	return &config
+}
+
+// creates a config with all default values
+func defaultConfig() IniFile {
+	config := IniFile{
+		&General{
+			ObsHost:     "localhost:4455",
+			ObsPassword: "",
+		},
+		&Midi{
+			PortIn:           "",
+			PortOut:          "",
+			ExtenderPortsIn:  []string{},
+			ExtenderPortsOut: []string{},
+		},
+		&Advanced{
+			SyncDelay:       100,
+			HoldTime:        1000,
+			DoublePressTime: 300,
+		},
+		&McuFaders{
+			ShowMeters:    false,
+			SimulateTouch: false,
+			//Fader1:      "",
+			//Fader2:      "",
+			//Fader3:      "",
+			//Fader4:      "",
+			//Fader5:      "",
+			//Fader6:      "",
+			//Fader7:      "",
+			//Fader8:      "",
+			FaderMaster: "",
+		},
+		&McuVpots{
+			//Vpot1: "",
+			//Vpot2: "",
+			//Vpot3: "",
+			//Vpot4: "",
+			//Vpot5: "",
+			//Vpot6: "",
+			//Vpot7: "",
+			//Vpot8: "",
+		},
+		&McuJog{
+			Mode:       "volume",
+			VolumeStep: 0.5,
+			MediaStep:  1000,
+		},
+		&McuDisplay{
+			Timecode: "scene",
+		},
+		&McuChannels{
+			Visibility:      "program",
+			VisibilityScene: "",
+			Include:         []string{},
+			Exclude:         []string{},
+			ExcludeKinds:    []string{},
+			Order:           []string{},
+			Pinned:          []string{},
+		},
+		&McuLeds{
+			//Rec1:             "",
+			//Rec2:             "",
+			//Rec3:             "",
+			//Rec4:             "",
+			//Rec5:             "",
+			//Rec6:             "",
+			//Rec7:             "",
+			//Rec8:             "",
+			//Solo1:            "",
+			//Solo2:            "",
+			//Solo3:            "",
+			//Solo4:            "",
+			//Solo5:            "",
+			//Solo6:            "",
+			//Solo7:            "",
+			//Solo8:            "",
+			//Mute1:            "",
+			//Mute2:            "",
+			//Mute3:            "",
+			//Mute4:            "",
+			//Mute5:            "",
+			//Mute6:            "",
+			//Mute7:            "",
+			//Mute8:            "",
+			//Select1:          "",
+			//Select2:          "",
+			//Select3:          "",
+			//Select4:          "",
+			//Select5:          "",
+			//Select6:          "",
+			//Select7:          "",
+			//Select8:          "",
+			//AssignTrack:      "",
+			//AssignSend:       "",
+			//AssignPan:        "",
+			//AssignPlugin:     "",
+			//AssignEQ:         "",
+			//AssignInstrument: "",
+			//Read:    "",
+			//Write:   "",
+			//Trim:    "",
+			//Touch:   "",
+			//Latch:   "",
+			//Group:   "",
+			Save:    "",
+			Undo:    "",
+			Marker:  "",
+			Nudge:   "",
+			Cycle:   "",
+			Drop:    "",
+			Replace: "",
+			Click:   "",
+			Solo:    "",
+			Rewind:  "",
+			FastFwd: "",
+			Stop:    "",
+			Play:    "STATE:StreamState,BLINK:StreamReconnecting",
+			Record:  "STATE:RecordState,BLINK:RecordPaused",
+			Zoom:    "",
+			Scrub:   "STATE:ScrubMode",
+		},
+		&McuButtons{
+			//Rec1:             "",
+			//Rec2:             "",
+			//Rec3:             "",
+			//Rec4:             "",
+			//Rec5:             "",
+			//Rec6:             "",
+			//Rec7:             "",
+			//Rec8:             "",
+			//Solo1:            "",
+			//Solo2:            "",
+			//Solo3:            "",
+			//Solo4:            "",
+			//Solo5:            "",
+			//Solo6:            "",
+			//Solo7:            "",
+			//Solo8:            "",
+			//Mute1:            "",
+			//Mute2:            "",
+			//Mute3:            "",
+			//Mute4:            "",
+			//Mute5:            "",
+			//Mute6:            "",
+			//Mute7:            "",
+			//Mute8:            "",
+			//Select1:          "",
+			//Select2:          "",
+			//Select3:          "",
+			//Select4:          "",
+			//Select5:          "",
+			//Select6:          "",
+			//Select7:          "",
+			//Select8:          "",
+			//V1:               "",
+			//V2:               "",
+			//V3:               "",
+			//V4:               "",
+			//V5:               "",
+			//V6:               "",
+			//V7:               "",
+			//V8:               "",
+			//AssignTrack:      "",
+			//AssignSend:       "",
+			//AssignPan:        "",
+			//AssignPlugin:     "",
+			//AssignEQ:         "",
+			//AssignInstrument: "",
+			//BankL:           "",
+			//BankR:           "",
+			//ChannelL:        "",
+			//ChannelR:        "",
+			//Flip:            "",
+			//GlobalView:      "",
+			//NameValue:       "",
+			//SMPTEBeats:      "",
+			F1:              "",
+			F2:              "",
+			F3:              "",
+			F4:              "",
+			F5:              "",
+			F6:              "",
+			F7:              "",
+			F8:              "",
+			MIDITracks:      "",
+			Inputs:          "",
+			AudioTracks:     "",
+			AudioInstrument: "",
+			Aux:             "",
+			Busses:          "",
+			Outputs:         "",
+			User:            "",
+			Shift:           "",
+			Option:          "",
+			Control:         "",
+			CMDAlt:          "",
+			//Read:            "",
+			//Write:           "",
+			//Trim:            "",
+			//Touch:           "",
+			//Latch:           "",
+			//Group:           "",
+			Save:    "",
+			Undo:    "",
+			Cancel:  "",
+			Enter:   "",
+			Marker:  "",
+			Nudge:   "",
+			Cycle:   "",
+			Drop:    "",
+			Replace: "",
+			Click:   "",
+			Solo:    "",
+			Rewind:  "",
+			FastFwd: "",
+			Stop:    "KEY:OBSBasic.ForceStopStreaming,OBSBasic.StopRecording",
+			Play:    "KEY:OBSBasic.StartStreaming",
+			Record:  "KEY:OBSBasic.StartRecording",
+			Up:      "",
+			Down:    "",
+			Left:    "",
+			Right:   "",
+			Zoom:    "",
+			Scrub:   "",
+			UserA:   "",
+			UserB:   "",
+			//Fader1:           "",
+			//Fader2:           "",
+			//Fader3:           "",
+			//Fader4:           "",
+			//Fader5:           "",
+			//Fader6:           "",
+			//Fader7:           "",
+			//Fader8:           "",
+			//FaderMaster:      "",
+		},
+		nil,
+		nil,
+	}
+	config.ButtonMap = getMapping(config.McuButtons, nil)
+	config.LedMap = getMapping(config.McuLeds, nil)
+	return config
 }
 
 func InitConfig() {
 	var err error
-	if configFilePath, err = xdg.ConfigFile("obs-mcu/obs-mcu.config"); err == nil {
-		// add any new values
-		var cfg *ini.File
-		if cfg, err = ini.Load(configFilePath); err == nil {
-			cfg.NameMapper = ini.TitleUnderscore
-			cfg.ValueMapper = os.ExpandEnv
-			if section, err := cfg.GetSection("general"); err == nil {
-				section.MapTo(&Config.General)
+	if configFilePath == "" {
+		configFilePath, err = getProfilePath(profileName)
+	}
+	if err == nil {
+		if _, err = os.Stat(configFilePath); os.IsNotExist(err) {
+			// create the config file with the default values
+			err = SaveConfig()
+		} else if err == nil {
+			// an existing config file is never written on start
+			var config IniFile
+			if config, err = loadConfig(configFilePath); err == nil {
+				current.Store(&config)
 			}
-			if section, err := cfg.GetSection("midi"); err == nil {
-				section.MapTo(&Config.Midi)
+		}
+	}
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+// WatchConfig checks the config file for changes every two seconds,
+// the config is replaced as a whole and changed is called after each reload
+// or after the profile was switched
+func WatchConfig(changed func()) {
+	path := GetConfigFilePath()
+	modTime := getModTime(path)
+	go func() {
+		for range time.Tick(2 * time.Second) {
+			switched := GetConfigFilePath()
+			modified := getModTime(switched)
+			if switched == path && modified.Equal(modTime) {
+				continue
 			}
-			if section, err := cfg.GetSection("advanced"); err == nil {
-				section.MapTo(&Config.Advanced)
+			path = switched
+			modTime = modified
+			config, err := loadConfig(path)
+			if err != nil {
+				log.Print(err)
+				continue
 			}
-			if section, err := cfg.GetSection("mcu_faders"); err == nil {
-				section.MapTo(&Config.McuFaders)
+			log.Print("Config file changed, reloading..")
+			current.Store(&config)
+			changed()
+		}
+	}()
+}
+
+// get the modification time of a config file
+func getModTime(path string) time.Time {
+	if info, err := os.Stat(path); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// ReadConfig loads the config file without saving it,
+// fails if the file can't be read
+func ReadConfig() error {
+	var err error
+	if configFilePath == "" {
+		if configFilePath, err = getProfilePath(profileName); err != nil {
+			return err
+		}
+	}
+	config, err := loadConfig(configFilePath)
+	if err != nil {
+		return err
+	}
+	current.Store(&config)
+	return nil
+}
+
+// CheckKeys reports all sections and keys of the config file
+// that don't exist in the config
+func CheckKeys() []error {
+	errs := []error{}
+	cfg, err := ini.LoadSources(loadOptions, configFilePath)
+	if err != nil {
+		return append(errs, err)
+	}
+	sections := getSections(Get())
+	for _, section := range cfg.Sections() {
+		value, ok := sections[section.Name()]
+		if !ok {
+			if section.Name() != ini.DefaultSection || len(section.Keys()) > 0 {
+				errs = append(errs, fmt.Errorf("unknown section [%s]", section.Name()))
 			}
-			if section, err := cfg.GetSection("mcu_vpots"); err == nil {
-				section.MapTo(&Config.McuVpots)
+			continue
+		}
+		known := make(map[string]bool)
+		t := reflect.TypeOf(value).Elem()
+		for i := 0; i < t.NumField(); i++ {
+			known[KeyName(t.Field(i).Name)] = true
+		}
+		for _, key := range section.Keys() {
+			if _, ok := SwitchByKey(key.Name()); ok && section.Name() == "mcu_leds" {
+				continue
 			}
-			if section, err := cfg.GetSection("mcu_leds"); err == nil {
-				section.MapTo(&Config.McuLeds)
+			if isButtonKey(key.Name()) && section.Name() == "mcu_buttons" {
+				continue
 			}
-			if section, err := cfg.GetSection("mcu_buttons"); err == nil {
-				section.MapTo(&Config.McuButtons)
+			if !known[key.Name()] {
+				errs = append(errs, fmt.Errorf("[%s] unknown key %s", section.Name(), key.Name()))
 			}
-			//TODO: only save if changes
-			err = SaveConfig()
-		} else {
-			err = SaveConfig()
 		}
 	}
+	return errs
+}
+
+// KeyName returns the key in the config file for a field name
+func KeyName(fieldName string) string {
+	return ini.TitleUnderscore(fieldName)
+}
+
+// get the sections of the config file and the structs they map to
+func getSections(config *IniFile) map[string]any {
+	return map[string]any{
+		"general":      config.General,
+		"midi":         config.Midi,
+		"advanced":     config.Advanced,
+		"mcu_faders":   config.McuFaders,
+		"mcu_vpots":    config.McuVpots,
+		"mcu_jog":      config.McuJog,
+		"mcu_display":  config.McuDisplay,
+		"mcu_channels": config.McuChannels,
+		"mcu_leds":     config.McuLeds,
+		"mcu_buttons":  config.McuButtons,
+	}
+}
+
+// loads a config file, values not in the file keep their defaults
+func loadConfig(path string) (IniFile, error) {
+	config := defaultConfig()
+	cfg, err := ini.LoadSources(loadOptions, path)
 	if err != nil {
-		log.Fatal(err.Error())
+		return config, err
 	}
+	cfg.NameMapper = ini.TitleUnderscore
+	sections := getSections(&config)
+	// environment variables are expanded in the settings but not in the
+	// commands of buttons and leds, the json of a request can contain a '$'
+	commands := []string{"mcu_buttons", "mcu_leds"}
+	cfg.ValueMapper = os.ExpandEnv
+	for name, value := range sections {
+		if section, err := cfg.GetSection(name); err == nil && !slices.Contains(commands, name) {
+			section.MapTo(value)
+		}
+	}
+	cfg.ValueMapper = nil
+	for _, name := range commands {
+		if section, err := cfg.GetSection(name); err == nil {
+			section.MapTo(sections[name])
+		}
+	}
+	config.ButtonMap = getMapping(config.McuButtons, cfg.Section("mcu_buttons"))
+	config.LedMap = getMapping(config.McuLeds, cfg.Section("mcu_leds"))
+	return config, nil
 }
 
 func SaveConfig() error {
-	cfg = ini.Empty()
+	cfg = ini.Empty(loadOptions)
 	cfg.NameMapper = ini.TitleUnderscore
-	cfg.ValueMapper = os.ExpandEnv
-	if err := ini.ReflectFromWithMapper(cfg, &Config, ini.TitleUnderscore); err == nil {
+	config := Get()
+	if err := ini.ReflectFromWithMapper(cfg, config, ini.TitleUnderscore); err == nil {
+		addMapping(cfg.Section("mcu_buttons"), config.ButtonMap)
+		addMapping(cfg.Section("mcu_leds"), config.LedMap)
 		if err := cfg.SaveTo(configFilePath); err != nil {
 			return err
 		}
@@ -507,6 +766,81 @@ func SaveConfig() error {
 	return nil
 }
 
+// number of extenders with both an input and an output port
+func (m *Midi) ExtenderCount() int {
+	return min(len(m.ExtenderPortsIn), len(m.ExtenderPortsOut))
+}
+
 func GetConfigFilePath() string {
+	pathLock.Lock()
+	defer pathLock.Unlock()
 	return configFilePath
 }
+
+// SetConfigFile sets a config file to load instead of a profile,
+// has to be called before InitConfig
+func SetConfigFile(path string) {
+	configFilePath = path
+	profileName = ""
+}
+
+// SetProfile selects the named profile from the config directory,
+// has to be called before InitConfig
+func SetProfile(name string) {
+	profileName = name
+	configFilePath = ""
+}
+
+// SwitchProfile loads the named profile from the config directory while
+// running, the watcher applies it like any other change of the config file
+func SwitchProfile(name string) error {
+	path, err := getProfilePath(name)
+	if err != nil {
+		return err
+	}
+	config, err := loadConfig(path)
+	if err != nil {
+		return err
+	}
+	pathLock.Lock()
+	defer pathLock.Unlock()
+	profileName = name
+	configFilePath = path
+	current.Store(&config)
+	return nil
+}
+
+// GetProfile returns the name of the current profile,
+// empty when an explicit config file is used
+func GetProfile() string {
+	pathLock.Lock()
+	defer pathLock.Unlock()
+	return profileName
+}
+
+// GetProfiles lists the names of all profiles in the config directory
+func GetProfiles() []string {
+	profiles := []string{}
+	path, err := getProfilePath(DefaultProfile)
+	if err != nil {
+		return profiles
+	}
+	files, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return profiles
+	}
+	for _, file := range files {
+		if name, found := strings.CutSuffix(file.Name(), ".config"); found && !file.IsDir() {
+			profiles = append(profiles, name)
+		}
+	}
+	return profiles
+}
+
+// get the path of the config file for a profile
+func getProfilePath(name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", errors.New("invalid profile name: " + name)
+	}
+	return xdg.ConfigFile("obs-mcu/" + name + ".config")
+}
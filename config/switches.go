@@ -0,0 +1,126 @@
+package config
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/normen/obs-mcu/gomcu"
+	"gopkg.in/ini.v1"
+)
+
+// mapping this value to a button or led disables its built-in function
+const Disabled = "NONE"
+
+// gestures of a button, appended to the button key, e.g. "record.hold"
+const (
+	GestureHold   = "hold"
+	GestureDouble = "double"
+)
+
+// the switches by their config key, e.g. "name_value" for gomcu.NameValue
+var switchKeys = make(map[string]gomcu.Switch)
+
+// the modifier buttons in the order they appear in a button key,
+// e.g. "shift+control+f1"
+var Modifiers = []gomcu.Switch{gomcu.Shift, gomcu.Option, gomcu.Control, gomcu.CMDAlt}
+
+func init() {
+	for i, name := range gomcu.Names {
+		switchKeys[KeyName(name)] = gomcu.Switch(i)
+	}
+}
+
+// SwitchKey returns the config key of a switch
+func SwitchKey(sw gomcu.Switch) string {
+	if int(sw) < len(gomcu.Names) {
+		return KeyName(gomcu.Names[sw])
+	}
+	return ""
+}
+
+// SwitchByKey returns the switch for a config key
+func SwitchByKey(key string) (gomcu.Switch, bool) {
+	sw, ok := switchKeys[key]
+	return sw, ok
+}
+
+// ButtonKey returns the config key of a button pressed while holding modifiers
+func ButtonKey(sw gomcu.Switch, held map[gomcu.Switch]bool) string {
+	key := ""
+	for _, modifier := range Modifiers {
+		if held[modifier] {
+			key += SwitchKey(modifier) + "+"
+		}
+	}
+	return key + SwitchKey(sw)
+}
+
+// GestureKey returns the config key of a button for a gesture,
+// the key is returned as is for an empty gesture
+func GestureKey(key string, gesture string) string {
+	if gesture == "" {
+		return key
+	}
+	return key + "." + gesture
+}
+
+// checks if a button key is a switch, optionally prefixed by modifiers
+// in the order of Modifiers and followed by a gesture
+func isButtonKey(key string) bool {
+	key, gesture, found := strings.Cut(key, ".")
+	if found && gesture != GestureHold && gesture != GestureDouble {
+		return false
+	}
+	parts := strings.Split(key, "+")
+	if _, ok := SwitchByKey(parts[len(parts)-1]); !ok {
+		return false
+	}
+	next := 0
+	for _, part := range parts[:len(parts)-1] {
+		found := false
+		for i := next; i < len(Modifiers) && !found; i++ {
+			if SwitchKey(Modifiers[i]) == part {
+				next = i + 1
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// creates the mapping of switch keys to values from the fields of a section struct,
+// overlaid with all keys of the section in the config file (if any)
+func getMapping(value any, section *ini.Section) map[string]string {
+	mapping := make(map[string]string)
+	s := reflect.ValueOf(value).Elem()
+	for i := 0; i < s.NumField(); i++ {
+		if s.Field(i).Kind() == reflect.String {
+			mapping[KeyName(s.Type().Field(i).Name)] = s.Field(i).String()
+		}
+	}
+	if section != nil {
+		for _, key := range section.Keys() {
+			mapping[key.Name()] = key.String()
+		}
+	}
+	return mapping
+}
+
+// adds the values of a mapping that are not yet in the section,
+// in alphabetical order
+func addMapping(section *ini.Section, mapping map[string]string) {
+	keys := make([]string, 0, len(mapping))
+	for key, value := range mapping {
+		if value != "" && !section.HasKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		section.NewKey(key, mapping[key])
+	}
+}
@@ -0,0 +1,30 @@
+package config
+
+import "testing"
+
+func TestIsButtonKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"f1", true},
+		{"record", true},
+		{"shift+f1", true},
+		{"shift+control+f1", true},
+		{"record.hold", true},
+		{"shift+record.double", true},
+		{"control+shift+f1", false},
+		{"shift+shift+f1", false},
+		{"f1+shift", false},
+		{"unknown", false},
+		{"shift+unknown", false},
+		{"record.long", false},
+		{"record.", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := isButtonKey(test.key); got != test.want {
+			t.Errorf("isButtonKey(%q) = %v, want %v", test.key, got, test.want)
+		}
+	}
+}
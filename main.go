@@ -28,21 +28,33 @@ import (
 var VERSION string = "v0.7.16"
 var waitGroup sync.WaitGroup
 
-// TODO: config file command line option
 func main() {
-	var showMidi, configureMidi, showHelp bool
+	var showMidi, configureMidi, showHelp, validate bool
+	var configFile, profile string
 	flag.BoolVar(&showMidi, "l", false, "List all installed MIDI devices")
 	flag.BoolVar(&configureMidi, "c", false, "Configure and start")
 	flag.BoolVar(&showHelp, "h", false, "Show Help")
 	flag.BoolVar(&obs.ExitWithObs, "x", false, "Exit when OBS exits")
 	flag.BoolVar(&obs.ShowHotkeyNames, "k", false, "Show all of OBS hotkey names after connecting")
+	flag.BoolVar(&validate, "validate", false, "Check the config file and exit")
+	flag.StringVar(&configFile, "f", "", "Load the given config file")
+	flag.StringVar(&profile, "p", "", "Load the named profile from the config directory")
 	flag.Parse()
 	log.Printf("OBS-MCU %v", VERSION)
+	if configFile != "" {
+		config.SetConfigFile(configFile)
+	} else if profile != "" {
+		config.SetProfile(profile)
+	}
 	if showHelp {
 		fmt.Println("Usage: obs-mcu [options]")
 		flag.PrintDefaults()
 	} else if showMidi {
 		ShowMidiPorts()
+	} else if validate {
+		if !ValidateConfig() {
+			os.Exit(1)
+		}
 	} else if configureMidi {
 		config.InitConfig()
 		if UserConfigure() {
@@ -50,7 +62,7 @@ func main() {
 		}
 	} else {
 		config.InitConfig()
-		if config.Config.Midi.PortIn == "" {
+		if config.Get().Midi.PortIn == "" {
 			if UserConfigure() {
 				startRunloops()
 			}
@@ -65,6 +77,7 @@ func startRunloops() {
 	fromObs := make(chan interface{}, 100)
 	obs.InitObs(fromMcu, fromObs, &waitGroup)
 	mcu.InitMcu(fromMcu, fromObs, &waitGroup)
+	config.WatchConfig(func() { fromMcu <- msg.ConfigReloadMessage{} })
 	if isHeadless() {
 		waitGroup.Wait()
 	} else {
@@ -105,7 +118,7 @@ func UserConfigure() bool {
 		fmt.Println("Please enter only valid numbers")
 		return false
 	}
-	config.Config.Midi.PortIn = inputs[num-1]
+	portIn := inputs[num-1]
 
 	fmt.Println()
 	outputs := mcu.GetMidiOutputs()
@@ -121,28 +134,35 @@ func UserConfigure() bool {
 		fmt.Println("Please enter only valid numbers")
 		return false
 	}
-	config.Config.Midi.PortOut = outputs[num-1]
+	portOut := outputs[num-1]
+	host := config.Get().General.ObsHost
+	password := config.Get().General.ObsPassword
 
 	fmt.Println()
 	fmt.Println()
 	fmt.Println("*** CONFIGURING OBS connection ***")
 	fmt.Println()
-	fmt.Printf("Enter OBS host and port or press enter for (%v): ", config.Config.General.ObsHost)
+	fmt.Printf("Enter OBS host and port or press enter for (%v): ", host)
 	text, _ = reader.ReadString('\n')
 	text = strings.TrimSpace(text)
 	if text != "" {
-		config.Config.General.ObsHost = text
+		host = text
 	}
 	fmt.Println()
 	fmt.Printf("Enter OBS password or press [enter] to keep current: ")
 	text, _ = reader.ReadString('\n')
 	text = strings.TrimSpace(text)
 	if text != "" {
-		config.Config.General.ObsPassword = text
+		password = text
 	}
 	fmt.Println()
 
-	err = config.SaveConfig()
+	err = config.Update(func(c *config.IniFile) {
+		c.Midi.PortIn = portIn
+		c.Midi.PortOut = portOut
+		c.General.ObsHost = host
+		c.General.ObsPassword = password
+	})
 	if err != nil {
 		log.Println(err)
 	}
@@ -159,16 +179,32 @@ func onReady() {
 	systray.SetTooltip("obs-mcu")
 	mOpenConfig := systray.AddMenuItem("Edit Config", "Open config file")
 	systray.AddSeparator()
-	mMidiInputs := systray.AddMenuItem("MIDI Input", "Select MIDI input (restart to apply)")
-	mMidiOutputs := systray.AddMenuItem("MIDI Output", "Select MIDI output (restart to apply)")
+	mMidiInputs := systray.AddMenuItem("MIDI Input", "Select MIDI input")
+	mMidiOutputs := systray.AddMenuItem("MIDI Output", "Select MIDI output")
 	systray.AddSeparator()
 	mSettings := systray.AddMenuItem("Settings", "Other Settings")
-	mShowMeters := mSettings.AddSubMenuItemCheckbox("Show Meters", "Show meters on MCU (restart to apply)", config.Config.McuFaders.ShowMeters)
-	mSimulateTouch := mSettings.AddSubMenuItemCheckbox("Simulate Touch", "Simulate touch on MCU for surfaces with no touch support (restart to apply)", config.Config.McuFaders.SimulateTouch)
+	mShowMeters := mSettings.AddSubMenuItemCheckbox("Show Meters", "Show meters on MCU", config.Get().McuFaders.ShowMeters)
+	mSimulateTouch := mSettings.AddSubMenuItemCheckbox("Simulate Touch", "Simulate touch on MCU for surfaces with no touch support", config.Get().McuFaders.SimulateTouch)
+	mProfiles := systray.AddMenuItem("Profiles", "Select config profile")
+	profiles := config.GetProfiles()
+	profileItems := make([]*systray.MenuItem, len(profiles))
+	for i, v := range profiles {
+		item := mProfiles.AddSubMenuItemCheckbox(v, "", config.GetProfile() == v)
+		profileItems[i] = item
+		val := v
+		go func() {
+			for {
+				<-item.ClickedCh
+				if val != config.GetProfile() {
+					fromUser <- msg.ProfileSetting{Name: val}
+				}
+			}
+		}()
+	}
 	inputs := mcu.GetMidiInputs()
 	inputItems := make([]*systray.MenuItem, len(inputs))
 	for i, v := range inputs {
-		selected := config.Config.Midi.PortIn == v
+		selected := config.Get().Midi.PortIn == v
 		item := mMidiInputs.AddSubMenuItemCheckbox(v, "", selected)
 		inputItems[i] = item
 		val := v
@@ -186,7 +222,7 @@ func onReady() {
 	outputs := mcu.GetMidiOutputs()
 	outputItems := make([]*systray.MenuItem, len(outputs))
 	for i, v := range outputs {
-		selected := config.Config.Midi.PortOut == v
+		selected := config.Get().Midi.PortOut == v
 		item := mMidiOutputs.AddSubMenuItemCheckbox(v, "", selected)
 		outputItems[i] = item
 		val := v
@@ -211,17 +247,15 @@ func onReady() {
 			case <-mOpenConfig.ClickedCh:
 				open.Run(config.GetConfigFilePath())
 			case <-mShowMeters.ClickedCh:
-				config.Config.McuFaders.ShowMeters = !config.Config.McuFaders.ShowMeters
-				config.SaveConfig()
-				if config.Config.McuFaders.ShowMeters {
+				config.Update(func(c *config.IniFile) { c.McuFaders.ShowMeters = !c.McuFaders.ShowMeters })
+				if config.Get().McuFaders.ShowMeters {
 					mShowMeters.Check()
 				} else {
 					mShowMeters.Uncheck()
 				}
 			case <-mSimulateTouch.ClickedCh:
-				config.Config.McuFaders.SimulateTouch = !config.Config.McuFaders.SimulateTouch
-				config.SaveConfig()
-				if config.Config.McuFaders.SimulateTouch {
+				config.Update(func(c *config.IniFile) { c.McuFaders.SimulateTouch = !c.McuFaders.SimulateTouch })
+				if config.Get().McuFaders.SimulateTouch {
 					mSimulateTouch.Check()
 				} else {
 					mSimulateTouch.Uncheck()
@@ -229,17 +263,42 @@ func onReady() {
 			case message := <-fromUser:
 				switch msg := message.(type) {
 				case msg.MidiInputSetting:
-					config.Config.Midi.PortIn = msg.PortName
-					config.SaveConfig()
+					config.Update(func(c *config.IniFile) { c.Midi.PortIn = msg.PortName })
 				case msg.MidiOutputSetting:
-					config.Config.Midi.PortOut = msg.PortName
-					config.SaveConfig()
+					config.Update(func(c *config.IniFile) { c.Midi.PortOut = msg.PortName })
+				case msg.ProfileSetting:
+					log.Printf("Switching to profile %s", msg.Name)
+					if err := config.SwitchProfile(msg.Name); err != nil {
+						log.Println(err)
+						break
+					}
+					checkItems(profileItems, profiles, msg.Name)
+					checkItems(inputItems, inputs, config.Get().Midi.PortIn)
+					checkItems(outputItems, outputs, config.Get().Midi.PortOut)
+					setChecked(mShowMeters, config.Get().McuFaders.ShowMeters)
+					setChecked(mSimulateTouch, config.Get().McuFaders.SimulateTouch)
 				}
 			}
 		}
 	}()
 }
 
+// checks the menu item with the given name and unchecks the others
+func checkItems(items []*systray.MenuItem, names []string, name string) {
+	for i, item := range items {
+		setChecked(item, names[i] == name)
+	}
+}
+
+// sets the check mark of a menu item
+func setChecked(item *systray.MenuItem, checked bool) {
+	if checked {
+		item.Check()
+	} else {
+		item.Uncheck()
+	}
+}
+
 // check if we run headless
 func isHeadless() bool {
 	_, display := os.LookupEnv("DISPLAY")
@@ -0,0 +1,162 @@
+package mcu
+
+import (
+	"log"
+	"time"
+
+	"github.com/normen/obs-mcu/gomcu"
+	"github.com/normen/obs-mcu/msg"
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+)
+
+// Device is one connected control surface, the main unit or an extender.
+// Each device shows 8 strips, starting at its Offset.
+type Device struct {
+	Index        int
+	Offset       byte
+	PortIn       string
+	PortOut      string
+	State        *McuState
+	input        drivers.In
+	output       drivers.Out
+	stop         func()
+	connectRetry *time.Timer
+}
+
+// NewDevice creates a new device with the given MIDI ports,
+// index 0 is the main unit
+func NewDevice(index int, portIn string, portOut string) *Device {
+	return &Device{
+		Index:   index,
+		Offset:  byte(index * 8),
+		PortIn:  portIn,
+		PortOut: portOut,
+	}
+}
+
+// IsExtender returns true if the device is an extender
+func (d *Device) IsExtender() bool {
+	return d.Index > 0
+}
+
+// connects to the device, called from runloop
+func (d *Device) connect() {
+	var err error
+	d.disconnect()
+
+	d.input, err = midi.FindInPort(d.PortIn)
+	if err != nil {
+		log.Printf("Could not find MIDI Input '%s'", d.PortIn)
+		d.retryConnect()
+		return
+	}
+
+	d.output, err = midi.FindOutPort(d.PortOut)
+	if err != nil {
+		log.Printf("Could not find MIDI Output '%s'", d.PortOut)
+		d.retryConnect()
+		return
+	}
+
+	err = d.input.Open()
+	if err != nil {
+		log.Printf("Could not open MIDI Input '%s'", d.PortIn)
+		d.retryConnect()
+		return
+	}
+	err = d.output.Open()
+	if err != nil {
+		log.Printf("Could not open MIDI Output '%s'", d.PortOut)
+		d.retryConnect()
+		return
+	}
+
+	index := d.Index
+	d.stop, err = midi.ListenTo(d.input, func(message midi.Message, timestamps int32) {
+		receiveMidi(message, timestamps, index)
+	})
+	if err != nil {
+		log.Print(err)
+		d.retryConnect()
+		return
+	}
+
+	d.reset()
+	if d.IsExtender() {
+		log.Printf("MIDI Extender %v Connected", d.Index)
+	} else {
+		log.Print("MIDI Connected")
+	}
+}
+
+// resets the hardware and starts with a fresh state,
+// obs sends all values again, called from runloop
+func (d *Device) reset() {
+	//TODO: reset
+	gomcu.Reset(d.output)
+	d.State = NewMcuState(d.output, d.IsExtender())
+	if !d.IsExtender() {
+		timecode.Update(d.State)
+	}
+	fromMcu <- msg.UpdateRequest{}
+}
+
+// disconnects from the device and stops reconnecting, called from runloop
+func (d *Device) remove() {
+	if d.connectRetry != nil {
+		d.connectRetry.Stop()
+	}
+	d.disconnect()
+}
+
+// disconnects from the device, called from runloop
+func (d *Device) disconnect() {
+	d.State = nil
+	if d.stop != nil {
+		d.stop()
+		d.stop = nil
+	}
+	if !d.IsExtender() {
+		clear(heldModifiers)
+	}
+	if d.input != nil {
+		err := d.input.Close()
+		if err != nil {
+			log.Print(err)
+		}
+		d.input = nil
+	}
+	if d.output != nil {
+		err := d.output.Close()
+		if err != nil {
+			log.Print(err)
+		}
+		d.output = nil
+	}
+}
+
+// retry connection after 3 seconds
+func (d *Device) retryConnect() {
+	log.Print("Retry MIDI connection..")
+	d.disconnect()
+	if d.connectRetry != nil {
+		d.connectRetry.Stop()
+	}
+	index := d.Index
+	d.connectRetry = time.AfterFunc(3*time.Second, func() { connection <- index })
+}
+
+// check if the midi connection is still open,
+// call reconnect if not
+func (d *Device) checkConnection() bool {
+	if d.input != nil {
+		if !d.input.IsOpen() {
+			d.retryConnect()
+			return false
+		}
+	} else {
+		return false
+	}
+	return true
+}
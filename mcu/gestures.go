@@ -0,0 +1,89 @@
+package mcu
+
+import (
+	"sync"
+	"time"
+
+	"github.com/normen/obs-mcu/config"
+)
+
+// a button press on the main unit that waits until its gesture is known
+type pendingPress struct {
+	tap     string
+	hold    string
+	double  string
+	pressed bool
+	presses int
+	timer   *time.Timer
+}
+
+// the pending presses by button, the midi callback and the timers access them
+var pendingPresses = make(map[uint8]*pendingPress)
+var pendingMutex sync.Mutex
+
+// handles press and release of buttons that have long or double press
+// commands, returns false if the button doesn't use gestures
+func handleGesture(k uint8, pressed bool) bool {
+	pendingMutex.Lock()
+	defer pendingMutex.Unlock()
+	p := pendingPresses[k]
+	if p == nil {
+		if !pressed {
+			return false
+		}
+		hold := getCommand(k, config.GestureHold)
+		double := getCommand(k, config.GestureDouble)
+		if hold == "" && double == "" {
+			return false
+		}
+		p = &pendingPress{
+			tap:    getCommand(k, ""),
+			hold:   hold,
+			double: double,
+		}
+		pendingPresses[k] = p
+	}
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	if pressed {
+		p.pressed = true
+		p.presses++
+		if p.presses > 1 {
+			finishGesture(k, p.double)
+		} else if p.hold != "" {
+			p.timer = time.AfterFunc(time.Duration(config.Get().Advanced.HoldTime)*time.Millisecond, func() {
+				pendingMutex.Lock()
+				defer pendingMutex.Unlock()
+				if pendingPresses[k] == p && p.pressed {
+					finishGesture(k, p.hold)
+				}
+			})
+		}
+	} else {
+		p.pressed = false
+		if p.double == "" {
+			finishGesture(k, p.tap)
+		} else {
+			p.timer = time.AfterFunc(time.Duration(config.Get().Advanced.DoublePressTime)*time.Millisecond, func() {
+				pendingMutex.Lock()
+				defer pendingMutex.Unlock()
+				if pendingPresses[k] == p {
+					finishGesture(k, p.tap)
+				}
+			})
+		}
+	}
+	return true
+}
+
+// runs the command of the detected gesture, the built-in function
+// for a tap without command, call with pendingMutex locked
+func finishGesture(k uint8, command string) {
+	delete(pendingPresses, k)
+	if command != "" {
+		runCommand(command)
+	} else {
+		runBuiltin(k, 0)
+	}
+}
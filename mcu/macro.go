@@ -0,0 +1,77 @@
+package mcu
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/normen/obs-mcu/msg"
+)
+
+// parses the steps of a macro, separated by "|", e.g.
+// "FADE:Music,-30,2000 | SCENE:Main | WAIT:500 | UNMUTE:Host Mic"
+func parseMacro(cmdString string) ([]msg.MacroStep, error) {
+	steps := []msg.MacroStep{}
+	for _, entry := range strings.Split(cmdString, "|") {
+		action, value, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found || value == "" {
+			return nil, fmt.Errorf("invalid macro step %q", entry)
+		}
+		step := msg.MacroStep{
+			Action: action,
+			Value:  value,
+		}
+		switch action {
+		case "KEY", "SCENE", "MUTE", "UNMUTE":
+		case "REQUEST":
+			requestType, requestData, err := parseRequest(value)
+			if err != nil {
+				return nil, err
+			}
+			step.Value = requestType
+			step.Data = requestData
+		case "WAIT":
+			ms, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid wait time %q", value)
+			}
+			step.Duration = time.Duration(ms) * time.Millisecond
+		case "FADE":
+			// the input name can contain commas
+			parts := strings.Split(value, ",")
+			if len(parts) < 3 {
+				return nil, fmt.Errorf("FADE needs an input, a volume in dB and a time: %q", value)
+			}
+			step.Value = strings.Join(parts[:len(parts)-2], ",")
+			db, err := parseDb(parts[len(parts)-2])
+			if err != nil {
+				return nil, err
+			}
+			step.Db = db
+			ms, err := strconv.Atoi(strings.TrimSpace(parts[len(parts)-1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid fade time %q", parts[len(parts)-1])
+			}
+			step.Duration = time.Duration(ms) * time.Millisecond
+		default:
+			return nil, fmt.Errorf("unknown macro step %q", action)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// parses a volume in dB, "-inf" is silence
+func parseDb(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "-inf" {
+		return math.Inf(-1), nil
+	}
+	db, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid volume %q", value)
+	}
+	return db, nil
+}
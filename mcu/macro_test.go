@@ -0,0 +1,77 @@
+package mcu
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/normen/obs-mcu/msg"
+)
+
+func TestParseMacro(t *testing.T) {
+	tests := []struct {
+		macro string
+		want  []msg.MacroStep
+	}{
+		{"SCENE:Main", []msg.MacroStep{{Action: "SCENE", Value: "Main"}}},
+		{"KEY:a,b | WAIT:500", []msg.MacroStep{
+			{Action: "KEY", Value: "a,b"},
+			{Action: "WAIT", Value: "500", Duration: 500 * time.Millisecond},
+		}},
+		{"MUTE:Host Mic|UNMUTE:Guest", []msg.MacroStep{
+			{Action: "MUTE", Value: "Host Mic"},
+			{Action: "UNMUTE", Value: "Guest"},
+		}},
+		{"FADE:Music,-30,2000", []msg.MacroStep{{Action: "FADE", Value: "Music", Db: -30, Duration: 2 * time.Second}}},
+		{"FADE:Music, Left,-6.5, 100", []msg.MacroStep{{Action: "FADE", Value: "Music, Left", Db: -6.5, Duration: 100 * time.Millisecond}}},
+		{"REQUEST:SaveReplayBuffer", []msg.MacroStep{{Action: "REQUEST", Value: "SaveReplayBuffer", Data: []byte{}}}},
+	}
+	for _, test := range tests {
+		steps, err := parseMacro(test.macro)
+		if err != nil {
+			t.Errorf("parseMacro(%q) failed: %v", test.macro, err)
+			continue
+		}
+		if len(steps) != len(test.want) {
+			t.Errorf("parseMacro(%q) has %d steps, want %d", test.macro, len(steps), len(test.want))
+			continue
+		}
+		for i, step := range steps {
+			want := test.want[i]
+			if step.Action != want.Action || step.Value != want.Value || step.Db != want.Db ||
+				step.Duration != want.Duration || string(step.Data) != string(want.Data) {
+				t.Errorf("parseMacro(%q) step %d = %+v, want %+v", test.macro, i, step, want)
+			}
+		}
+	}
+}
+
+func TestParseMacroFadeToSilence(t *testing.T) {
+	steps, err := parseMacro("FADE:Music,-inf,1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !math.IsInf(steps[0].Db, -1) {
+		t.Errorf("FADE to -inf has %v dB", steps[0].Db)
+	}
+}
+
+func TestParseMacroErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"SCENE",
+		"SCENE:",
+		"SCENE:Main |",
+		"JUMP:Main",
+		"WAIT:soon",
+		"FADE:Music,-30",
+		"FADE:Music,loud,2000",
+		"FADE:Music,-30,long",
+		`REQUEST:SetInputMute {"inputName": }`,
+	}
+	for _, macro := range tests {
+		if _, err := parseMacro(macro); err == nil {
+			t.Errorf("parseMacro(%q) should fail", macro)
+		}
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
-	"reflect"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -13,24 +13,29 @@ import (
 	"github.com/normen/obs-mcu/gomcu"
 	"github.com/normen/obs-mcu/msg"
 	"gitlab.com/gomidi/midi/v2"
-	"gitlab.com/gomidi/midi/v2/drivers"
 	_ "gitlab.com/gomidi/midi/v2/drivers/rtmididrv" // autoregisters driver
 )
 
 var waitGroup *sync.WaitGroup
-var state *McuState
 
-var midiInput drivers.In
-var midiOutput drivers.Out
-var midiStop func()
+// the main unit is always the first device, extenders follow
+var devices []*Device
+var timecode *Timecode
 
-var connectRetry *time.Timer
 var fromObs chan interface{}
 var fromMcu chan interface{}
 var internalMcu chan interface{}
 var obsOutputChannel chan interface{}
 var interrupt chan os.Signal
 var connection chan int
+var touchTicker *time.Ticker
+var noticeTimer *time.Timer
+
+// interval for updating the simulated fader touches
+const touchInterval = 300 * time.Millisecond
+
+// time a notice is shown on the lcd
+const noticeDuration = 1500 * time.Millisecond
 
 // get a list of midi outputs
 func GetMidiOutputs() []string {
@@ -60,228 +65,228 @@ func InitMcu(fMcu chan interface{}, fObs chan interface{}, wg *sync.WaitGroup) {
 	InitInterp()
 	internalMcu = make(chan interface{})
 	interrupt = make(chan os.Signal, 1)
-	connection = make(chan int, 1)
+	count := config.Get().Midi.ExtenderCount()
+	connection = make(chan int, count+1)
 	signal.Notify(interrupt, os.Interrupt)
-	state = NewMcuState()
-	connection <- 0
+	timecode = NewTimecode(config.Get().McuDisplay.Timecode)
+	devices = []*Device{}
+	for i := 0; i <= count; i++ {
+		portIn, portOut := getPorts(i)
+		devices = append(devices, NewDevice(i, portIn, portOut))
+		connection <- i
+	}
 	wg.Add(1)
 	go runLoop()
 }
 
-// connects to the MCU, called from runloop
-func connect() {
-	var err error
-	disconnect()
-
-	midiInput, err = midi.FindInPort(config.Config.Midi.PortIn)
-	if err != nil {
-		log.Printf("Could not find MIDI Input '%s'", config.Config.Midi.PortIn)
-		retryConnect()
-		return
-	}
-
-	midiOutput, err = midi.FindOutPort(config.Config.Midi.PortOut)
-	if err != nil {
-		log.Printf("Could not find MIDI Output '%s'", config.Config.Midi.PortOut)
-		retryConnect()
-		return
+// get the configured ports of the device with the given index
+func getPorts(index int) (string, string) {
+	if index == 0 {
+		return config.Get().Midi.PortIn, config.Get().Midi.PortOut
 	}
+	return config.Get().Midi.ExtenderPortsIn[index-1], config.Get().Midi.ExtenderPortsOut[index-1]
+}
 
-	err = midiInput.Open()
-	if err != nil {
-		log.Printf("Could not open MIDI Input '%s'", config.Config.Midi.PortOut)
-		retryConnect()
-		return
-	}
-	err = midiOutput.Open()
-	if err != nil {
-		log.Printf("Could not open MIDI Output '%s'", config.Config.Midi.PortOut)
-		retryConnect()
-		return
+// applies a reloaded config, devices with changed ports are reconnected
+// and all others are reset so obs syncs them again, called from runloop
+func reloadConfig() {
+	if config.Get().McuFaders.SimulateTouch {
+		touchTicker.Reset(touchInterval)
+	} else {
+		touchTicker.Stop()
 	}
-
-	//TODO: reset
-	gomcu.Reset(midiOutput)
-
-	midiStop, err = midi.ListenTo(midiInput, receiveMidi)
-	if err != nil {
-		log.Print(err)
-		retryConnect()
-		return
+	count := config.Get().Midi.ExtenderCount()
+	for i := count + 1; i < len(devices); i++ {
+		devices[i].remove()
 	}
-
-	send, err := midi.SendTo(midiOutput)
-	if err != nil {
-		log.Print(err)
-		retryConnect()
-		return
+	reloaded := []*Device{}
+	added := []*Device{}
+	for i := 0; i <= count; i++ {
+		portIn, portOut := getPorts(i)
+		if i < len(devices) && devices[i].PortIn == portIn && devices[i].PortOut == portOut {
+			reloaded = append(reloaded, devices[i])
+			if devices[i].State != nil {
+				devices[i].reset()
+			}
+			continue
+		}
+		if i < len(devices) {
+			devices[i].remove()
+		}
+		device := NewDevice(i, portIn, portOut)
+		reloaded = append(reloaded, device)
+		added = append(added, device)
 	}
-
-	//m := []midi.Message{gomcu.SetDigit(gomcu.AssignLeft, 'H'), gomcu.SetDigit(gomcu.AssignRight, 'W'), gomcu.SetLCD(0, "Hello,"), gomcu.SetLCD(56, "World")}
-	m := []midi.Message{}
-	m = append(m, gomcu.SetTimeDisplay("OBS Studio")...)
-	for _, msg := range m {
-		send(msg)
+	devices = reloaded
+	for _, d := range added {
+		d.connect()
 	}
-	fromMcu <- msg.UpdateRequest{}
-	log.Print("MIDI Connected")
 }
 
-// disconnects from the MCU, called from runloop
+// disconnects all devices, called from runloop
 func disconnect() {
-	//debug.PrintStack()
-	if midiStop != nil {
-		midiStop()
-		midiStop = nil
+	for _, d := range devices {
+		d.disconnect()
 	}
-	if midiInput != nil {
-		err := midiInput.Close()
-		if err != nil {
-			log.Print(err)
-		}
-		midiInput = nil
-	}
-	if midiOutput != nil {
-		err := midiOutput.Close()
-		if err != nil {
-			log.Print(err)
+}
+
+// check if the midi connections are still open,
+// call reconnect for closed ones, returns true if any is connected
+func checkMidiConnection() bool {
+	connected := false
+	for _, d := range devices {
+		if d.checkConnection() {
+			connected = true
 		}
-		midiOutput = nil
 	}
+	return connected
 }
 
-// retry connection after 3 seconds
-func retryConnect() {
-	log.Print("Retry MIDI connection..")
-	disconnect()
-	if connectRetry != nil {
-		connectRetry.Stop()
+// translate a led state from obs to the hardware state
+func ledState(state msg.LedState) gomcu.State {
+	switch state {
+	case msg.LedOn:
+		return gomcu.StateOn
+	case msg.LedBlink:
+		return gomcu.StateBlinking
 	}
-	connectRetry = time.AfterFunc(3*time.Second, func() { connection <- 0 })
+	return gomcu.StateOff
 }
 
-// check if midi connection is still open,
-// call reconnect if not
-func checkMidiConnection() bool {
-	if midiInput != nil {
-		if !midiInput.IsOpen() {
-			retryConnect()
-			return false
-		}
-	} else {
-		return false
+// get the state of the main unit, nil if not connected
+func mainState() *McuState {
+	return devices[0].State
+}
+
+// get the state and the local fader number for a fader number
+// of the combined surface, nil if that device is not connected
+func stripState(fader byte) (*McuState, byte) {
+	index := int(fader / 8)
+	if index < len(devices) {
+		return devices[index].State, fader % 8
 	}
-	return true
+	return nil, 0
 }
 
-// get the command for a button from the config
-func getCommand(k uint8) string {
-	if len(gomcu.Names) > int(k) {
-		fieldName := gomcu.Names[k]
-		s := reflect.ValueOf(config.Config.McuButtons).Elem()
-		fieldVal := s.FieldByName(fieldName)
-		if fieldVal.IsValid() && fieldVal.Kind() == reflect.String {
-			command := fieldVal.String()
-			log.Printf("Got button %s, Command: %s", fieldName, command)
-			return command
-		}
+// the modifier buttons that are currently held,
+// only used by the midi callback of the main unit
+var heldModifiers = make(map[gomcu.Switch]bool)
+
+// checks if a button is used as a modifier, modifiers with a command are normal buttons
+func isModifier(k uint8) bool {
+	return slices.Contains(config.Modifiers, gomcu.Switch(k)) && config.Get().ButtonMap[config.SwitchKey(gomcu.Switch(k))] == ""
+}
+
+// get the command for a button and a gesture (empty for a normal press) from the config,
+// uses the mapping with the held modifiers if there is one,
+// empty if the button keeps its built-in function
+func getCommand(k uint8, gesture string) string {
+	key := config.GestureKey(config.ButtonKey(gomcu.Switch(k), heldModifiers), gesture)
+	command := config.Get().ButtonMap[key]
+	if command == "" {
+		key = config.GestureKey(config.SwitchKey(gomcu.Switch(k)), gesture)
+		command = config.Get().ButtonMap[key]
+	}
+	if command != "" {
+		log.Printf("Got button %s, Command: %s", key, command)
 	}
-	return ""
+	return command
 }
 
-// send a list of midi messages
-func sendMidi(m []midi.Message) {
-	send, err := midi.SendTo(midiOutput)
+// runs a command from the button config
+func runCommand(command string) {
+	if command == config.Disabled {
+		return
+	}
+	cmdType, cmdString, err := parseCommand(command)
 	if err != nil {
 		log.Print(err)
 		return
 	}
-	for _, msg := range m {
-		send(msg)
+	switch cmdType {
+	case "KEY":
+		//send obs key
+		keynames := strings.Split(cmdString, ",")
+		for _, key := range keynames {
+			fromMcu <- msg.KeyMessage{
+				HotkeyName: key,
+			}
+			log.Printf("Send Key: %s", key)
+		}
+	case "SCENE":
+		fromMcu <- msg.SceneMessage{
+			SceneName: cmdString,
+		}
+	case "STUDIO":
+		fromMcu <- msg.StudioModeMessage{
+			Action: cmdString,
+		}
+	case "TRANSITION":
+		fromMcu <- msg.TransitionMessage{
+			TransitionName: cmdString,
+		}
+	case "REQUEST":
+		requestType, requestData, _ := parseRequest(cmdString)
+		fromMcu <- msg.RequestMessage{
+			RequestType: requestType,
+			RequestData: requestData,
+		}
+	case "MACRO":
+		steps, _ := parseMacro(cmdString)
+		fromMcu <- msg.MacroMessage{
+			Name:  cmdString,
+			Steps: steps,
+		}
 	}
 }
 
-// receives midi messages from the MCU, called from midi runloop!
-func receiveMidi(message midi.Message, timestamps int32) {
+// receives midi messages from the MCU or an extender with the given index,
+// called from midi runloop!
+func receiveMidi(message midi.Message, timestamps int32, index int) {
 	var c, k, v uint8
 	var val int16
 	var uval uint16
+	offset := byte(index * 8)
 	if message.GetNoteOn(&c, &k, &v) {
 		// fader touch - handle locally
-		if gomcu.Switch(k) >= gomcu.Fader1 && gomcu.Switch(k) <= gomcu.Fader8 {
+		if gomcu.Switch(k) >= gomcu.Fader1 && gomcu.Switch(k) <= gomcu.FaderMaster {
 			internalMcu <- msg.RawFaderTouchMessage{
+				Device:      index,
 				FaderNumber: k - byte(gomcu.Fader1),
 				Pressed:     v == 127,
 			}
+			if index == 0 && gomcu.Switch(k) == gomcu.FaderMaster {
+				fromMcu <- msg.MasterTouchMessage{
+					Pressed: v == 127,
+				}
+			}
+		}
+		// modifiers are tracked while held
+		if index == 0 && isModifier(k) {
+			heldModifiers[gomcu.Switch(k)] = v != 0
+			internalMcu <- msg.ModifierMessage{
+				Modifier: k,
+				Pressed:  v != 0,
+			}
+			return
+		}
+		// buttons with long or double press commands wait for the gesture
+		if index == 0 && handleGesture(k, v != 0) {
+			return
 		}
 		// avoid noteoffs for the other commands
 		if v == 0 {
 			return
 		}
-		if gomcu.Switch(k) >= gomcu.BankL && gomcu.Switch(k) <= gomcu.ChannelR {
-			var amount int
-			switch gomcu.Switch(k) {
-			case gomcu.BankL:
-				amount = -8
-			case gomcu.BankR:
-				amount = 8
-			case gomcu.ChannelL:
-				amount = -1
-			case gomcu.ChannelR:
-				amount = 1
-			}
-			fromMcu <- msg.BankMessage{
-				ChangeAmount: amount,
-			}
-		} else if gomcu.Switch(k) >= gomcu.V1 && gomcu.Switch(k) <= gomcu.V8 {
-			fromMcu <- msg.VPotButtonMessage{
-				FaderNumber: k - byte(gomcu.V1),
-			}
-		} else if gomcu.Switch(k) >= gomcu.Mute1 && gomcu.Switch(k) <= gomcu.Mute8 {
-			fromMcu <- msg.MuteMessage{
-				FaderNumber: k - byte(gomcu.Mute1),
-			}
-		} else if gomcu.Switch(k) >= gomcu.Rec1 && gomcu.Switch(k) <= gomcu.Rec8 {
-			fromMcu <- msg.MonitorTypeMessage{
-				FaderNumber: k,
-				MonitorType: "OBS_MONITORING_TYPE_MONITOR_ONLY",
-			}
-		} else if gomcu.Switch(k) >= gomcu.Solo1 && gomcu.Switch(k) <= gomcu.Solo8 {
-			fromMcu <- msg.MonitorTypeMessage{
-				FaderNumber: k - byte(gomcu.Solo1),
-				MonitorType: "OBS_MONITORING_TYPE_MONITOR_AND_OUTPUT",
-			}
-		} else if gomcu.Switch(k) >= gomcu.Select1 && gomcu.Switch(k) <= gomcu.Select8 {
-			fromMcu <- msg.SelectMessage{
-				FaderNumber: k - byte(gomcu.Select1),
-			}
-		} else if gomcu.Switch(k) >= gomcu.Read && gomcu.Switch(k) <= gomcu.Group {
-			fromMcu <- msg.TrackEnableMessage{
-				TrackNumber: k - byte(gomcu.Read),
-			}
-		} else if gomcu.Switch(k) >= gomcu.AssignTrack && gomcu.Switch(k) <= gomcu.AssignInstrument {
-			fromMcu <- msg.AssignMessage{
-				Mode: k - byte(gomcu.AssignTrack),
-			}
-		} else if len(gomcu.Names) > int(k) {
-			configValue := getCommand(k)
-			if len(configValue) > 0 {
-				cmdType, cmdString, found := strings.Cut(configValue, ":")
-				if found {
-					switch cmdType {
-					case "KEY":
-						//send obs key
-						keynames := strings.Split(cmdString, ",")
-						for _, key := range keynames {
-							fromMcu <- msg.KeyMessage{
-								HotkeyName: key,
-							}
-							log.Printf("Send Key: %s", key)
-						}
-					}
-				}
+		// commands from the config replace the built-in functions,
+		// extenders always use the built-in functions
+		if index == 0 {
+			if command := getCommand(k, ""); command != "" {
+				runCommand(command)
+				return
 			}
 		}
+		runBuiltin(k, index)
 	} else if message.GetControlChange(&c, &k, &v) {
 		if gomcu.Switch(k) >= 0x10 && gomcu.Switch(k) <= 0x17 {
 			amount := 0
@@ -291,44 +296,130 @@ func receiveMidi(message midi.Message, timestamps int32) {
 				amount = -1 * (int(v) - 64)
 			}
 			fromMcu <- msg.VPotChangeMessage{
-				FaderNumber:  k - 0x10,
+				FaderNumber:  k - 0x10 + offset,
+				ChangeAmount: amount,
+			}
+		} else if k == 0x3C {
+			// jog wheel
+			amount := 0
+			if v < 65 {
+				amount = int(v)
+			} else {
+				amount = -1 * (int(v) - 64)
+			}
+			fromMcu <- msg.JogMessage{
 				ChangeAmount: amount,
 			}
 		}
 
 	} else if message.GetPitchBend(&c, &val, &uval) {
 		internalMcu <- msg.RawFaderMessage{
+			Device:      index,
 			FaderNumber: c,
 			FaderValue:  val,
 		}
-		ival := IntToFaderFloat(val)
-		fromMcu <- msg.FaderMessage{
-			FaderNumber: c,
-			FaderValue:  ival,
+		position := float64(int(val)+8192) / gomcu.FaderMax
+		if gomcu.Channel(c) == gomcu.Master {
+			fromMcu <- msg.MasterFaderMessage{
+				Position: position,
+			}
+		} else {
+			ival := IntToFaderFloat(val)
+			fromMcu <- msg.FaderMessage{
+				FaderNumber: c + offset,
+				FaderValue:  ival,
+				Position:    position,
+			}
 		}
 	}
 
 }
 
+// runs the built-in function of a button
+func runBuiltin(k uint8, index int) {
+	offset := byte(index * 8)
+	if gomcu.Switch(k) >= gomcu.BankL && gomcu.Switch(k) <= gomcu.ChannelR {
+		// banks move the whole combined surface, the obs runloop
+		// knows its size so devices isn't read from the midi callback
+		var amount int
+		switch gomcu.Switch(k) {
+		case gomcu.BankL, gomcu.ChannelL:
+			amount = -1
+		case gomcu.BankR, gomcu.ChannelR:
+			amount = 1
+		}
+		fromMcu <- msg.BankMessage{
+			ChangeAmount: amount,
+			Page:         gomcu.Switch(k) == gomcu.BankL || gomcu.Switch(k) == gomcu.BankR,
+		}
+	} else if gomcu.Switch(k) >= gomcu.V1 && gomcu.Switch(k) <= gomcu.V8 {
+		fromMcu <- msg.VPotButtonMessage{
+			FaderNumber: k - byte(gomcu.V1) + offset,
+		}
+	} else if gomcu.Switch(k) >= gomcu.Mute1 && gomcu.Switch(k) <= gomcu.Mute8 {
+		fromMcu <- msg.MuteMessage{
+			FaderNumber: k - byte(gomcu.Mute1) + offset,
+		}
+	} else if gomcu.Switch(k) >= gomcu.Rec1 && gomcu.Switch(k) <= gomcu.Rec8 {
+		fromMcu <- msg.MonitorTypeMessage{
+			FaderNumber: k - byte(gomcu.Rec1) + offset,
+			MonitorType: "OBS_MONITORING_TYPE_MONITOR_ONLY",
+		}
+	} else if gomcu.Switch(k) >= gomcu.Solo1 && gomcu.Switch(k) <= gomcu.Solo8 {
+		fromMcu <- msg.MonitorTypeMessage{
+			FaderNumber: k - byte(gomcu.Solo1) + offset,
+			MonitorType: "OBS_MONITORING_TYPE_MONITOR_AND_OUTPUT",
+		}
+	} else if gomcu.Switch(k) >= gomcu.Select1 && gomcu.Switch(k) <= gomcu.Select8 {
+		fromMcu <- msg.SelectMessage{
+			FaderNumber: k - byte(gomcu.Select1) + offset,
+		}
+	} else if gomcu.Switch(k) >= gomcu.Read && gomcu.Switch(k) <= gomcu.Group {
+		fromMcu <- msg.TrackEnableMessage{
+			TrackNumber: k - byte(gomcu.Read),
+		}
+	} else if gomcu.Switch(k) >= gomcu.AssignTrack && gomcu.Switch(k) <= gomcu.AssignInstrument {
+		fromMcu <- msg.AssignMessage{
+			Mode: k - byte(gomcu.AssignTrack),
+		}
+	} else if gomcu.Switch(k) == gomcu.NameValue {
+		fromMcu <- msg.NameValueMessage{}
+	} else if gomcu.Switch(k) == gomcu.Flip {
+		fromMcu <- msg.FlipMessage{}
+	} else if gomcu.Switch(k) == gomcu.SMPTEBeats {
+		internalMcu <- msg.TimecodeModeMessage{}
+	} else if gomcu.Switch(k) == gomcu.Scrub {
+		fromMcu <- msg.ScrubMessage{}
+	} else if gomcu.Switch(k) == gomcu.GlobalView {
+		fromMcu <- msg.VisibilityMessage{}
+	}
+}
+
 // runloop for the MCU
 // only writes messages, reader is already looping
 func runLoop() {
-	// TODO: avoid ticker altogether when no touch
-	var timec <-chan time.Time
-	if config.Config.McuFaders.SimulateTouch {
-		timec = time.NewTicker(300 * time.Millisecond).C
-	} else {
-		timec = make(<-chan time.Time)
+	touchTicker = time.NewTicker(touchInterval)
+	if !config.Get().McuFaders.SimulateTouch {
+		touchTicker.Stop()
 	}
+	clock := time.NewTicker(time.Second)
 	for {
 		select {
-		case <-timec:
-			if config.Config.McuFaders.SimulateTouch {
-				state.UpdateTouch()
+		case <-clock.C:
+			if state := mainState(); state != nil {
+				timecode.Update(state)
 			}
-		case state := <-connection:
-			if state == 0 {
-				connect()
+		case <-touchTicker.C:
+			if config.Get().McuFaders.SimulateTouch {
+				for _, d := range devices {
+					if d.State != nil {
+						d.State.UpdateTouch()
+					}
+				}
+			}
+		case index := <-connection:
+			if index < len(devices) {
+				devices[index].connect()
 			}
 		case <-interrupt:
 			log.Print("Ending MCU runloop")
@@ -336,48 +427,131 @@ func runLoop() {
 			waitGroup.Done()
 			return
 		case message := <-fromObs:
-			if !checkMidiConnection() {
+			if _, ok := message.(msg.ConfigReloadMessage); ok {
+				reloadConfig()
 				continue
 			}
-			switch e := message.(type) {
-			case msg.FaderMessage:
-				state.SetFaderLevel(e.FaderNumber, e.FaderValue)
-			case msg.TrackEnableMessage:
-				state.SetTrackEnabledState(e.TrackNumber, e.Value)
-			case msg.MuteMessage:
-				state.SetMuteState(e.FaderNumber, e.Value)
-			case msg.ChannelTextMessage:
-				state.SetChannelText(e.FaderNumber, e.Text, e.Lower)
-			case msg.DisplayTextMessage:
-				state.SetDisplayText(e.Text)
-			case msg.AssignLEDMessage:
-				state.SetAssignText(e.Characters)
-			case msg.MonitorTypeMessage:
-				state.SetMonitorState(e.FaderNumber, e.MonitorType)
-			case msg.SelectMessage:
-				state.SetSelectState(e.FaderNumber, e.Value)
-			case msg.AssignMessage:
-				state.SetAssignMode(e.Mode)
-			case msg.VPotLedMessage:
-				state.SetVPotLed(e.FaderNumber, e.LedState)
-			case msg.MeterMessage:
-				state.SetMeter(e.FaderNumber, e.Value)
-			case msg.LedMessage:
-				if num, ok := gomcu.IDs[e.LedName]; ok {
-					state.SendLed(byte(num), e.LedState)
-				} else {
-					log.Printf("Could not find led with id %v", e.LedName)
-				}
+			if !checkMidiConnection() {
+				continue
 			}
+			processObsMessage(message)
 		case message := <-internalMcu:
 			switch e := message.(type) {
 			case msg.RawFaderMessage:
-				if config.Config.McuFaders.SimulateTouch {
-					state.SetFaderTouched(e.FaderNumber, true)
+				// the device might have been removed by a config reload
+				if config.Get().McuFaders.SimulateTouch && e.Device < len(devices) {
+					if state := devices[e.Device].State; state != nil {
+						state.SetFaderTouched(e.FaderNumber, true)
+					}
 				}
 			case msg.RawFaderTouchMessage:
-				state.SetFaderTouched(e.FaderNumber, e.Pressed)
+				if e.Device < len(devices) {
+					if state := devices[e.Device].State; state != nil {
+						state.SetFaderTouched(e.FaderNumber, e.Pressed)
+					}
+				}
+			case msg.ModifierMessage:
+				if state := mainState(); state != nil {
+					state.SendLed(e.Modifier, e.Pressed)
+				}
+			case msg.TimecodeModeMessage:
+				timecode.NextMode()
+				if state := mainState(); state != nil {
+					timecode.Update(state)
+				}
+			case msg.NoticeEndMessage:
+				if state := mainState(); state != nil {
+					state.RestoreText()
+				}
+			}
+		}
+	}
+}
+
+// sends a message from obs to the device it belongs to,
+// strip messages are routed by their fader number
+func processObsMessage(message interface{}) {
+	switch e := message.(type) {
+	case msg.FaderMessage:
+		if state, fader := stripState(e.FaderNumber); state != nil {
+			state.SetFaderLevel(fader, e.FaderValue)
+		}
+	case msg.FaderPositionMessage:
+		if state, fader := stripState(e.FaderNumber); state != nil {
+			state.SetFaderLinear(fader, e.Position)
+		}
+	case msg.MuteMessage:
+		if state, fader := stripState(e.FaderNumber); state != nil {
+			state.SetMuteState(fader, e.Value)
+		}
+	case msg.ChannelTextMessage:
+		if state, fader := stripState(e.FaderNumber); state != nil {
+			state.SetChannelText(fader, e.Text, e.Lower)
+		}
+	case msg.MonitorTypeMessage:
+		if state, fader := stripState(e.FaderNumber); state != nil {
+			state.SetMonitorState(fader, e.MonitorType)
+		}
+	case msg.TallyMessage:
+		if state, fader := stripState(e.FaderNumber); state != nil {
+			state.SetTallyState(fader, e.Program, e.Preview)
+		}
+	case msg.VPotLedMessage:
+		if state, fader := stripState(e.FaderNumber); state != nil {
+			state.SetVPotLed(fader, e.LedState)
+		}
+	case msg.MeterMessage:
+		if state, fader := stripState(e.FaderNumber); state != nil {
+			state.SetMeter(fader, e.Value)
+		}
+	case msg.SelectMessage:
+		// only one strip of all devices can be selected
+		for _, d := range devices {
+			if d.State == nil {
+				continue
+			}
+			if e.Value && e.FaderNumber >= d.Offset && e.FaderNumber < d.Offset+8 {
+				d.State.SetSelectState(e.FaderNumber-d.Offset, true)
+			} else {
+				d.State.SetSelectState(0, false)
+			}
+		}
+	}
+	// the timecode is kept while disconnected
+	switch e := message.(type) {
+	case msg.DisplayTextMessage:
+		timecode.SceneName = e.Text
+	case msg.OutputTimeMessage:
+		timecode.SetOutput(e.Output, e.Active, e.Paused, e.Duration)
+	}
+	state := mainState()
+	if state == nil {
+		return
+	}
+	switch e := message.(type) {
+	case msg.MasterFaderMessage:
+		state.SetMasterFader(e.Position)
+	case msg.TrackEnableMessage:
+		state.SetTrackEnabledState(e.TrackNumber, e.Value)
+	case msg.DisplayTextMessage, msg.OutputTimeMessage:
+		timecode.Update(state)
+	case msg.NoticeMessage:
+		state.ShowNotice(e.Text)
+		if noticeTimer != nil {
+			noticeTimer.Stop()
+		}
+		noticeTimer = time.AfterFunc(noticeDuration, func() { internalMcu <- msg.NoticeEndMessage{} })
+	case msg.AssignLEDMessage:
+		state.SetAssignText(e.Characters)
+	case msg.AssignMessage:
+		state.SetAssignMode(e.Mode)
+	case msg.LedMessage:
+		if num, ok := config.SwitchByKey(e.LedName); ok {
+			if !e.Builtin || !state.IsMapped(byte(num)) {
+				state.SendLedState(byte(num), ledState(e.LedState))
 			}
+		} else {
+			log.Printf("Could not find led with id %v", e.LedName)
 		}
 	}
 }
@@ -3,11 +3,13 @@ package mcu
 import (
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/normen/obs-mcu/config"
 	"github.com/normen/obs-mcu/gomcu"
 	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
 )
 
 // McuState stores the current state of the MCU
@@ -15,30 +17,37 @@ import (
 type McuState struct {
 	// TODO: combine
 	FaderLevels         []int16
-	FaderLevelsBuffered []float64
+	FaderLevelsBuffered []int16
 	FaderTouch          []bool
 	MeterLevels         []byte
 	FaderTouchTimeout   []time.Time
-	LedStates           map[byte]bool
+	LedStates           map[byte]gomcu.State
 	VPotLedStates       map[byte]byte
 	Text                string
-	Display             string
+	Notice              bool
+	Digits              []gomcu.Char
 	Assign              []rune
 	Debug               bool
+	Extender            bool
+	Output              drivers.Out
 }
 
-// NewMcuState creates a new McuState
-func NewMcuState() *McuState {
+// NewMcuState creates a new McuState for the main unit or an extender
+// that sends to the given MIDI output
+func NewMcuState(output drivers.Out, extender bool) *McuState {
 	state := McuState{}
+	state.Output = output
+	state.Extender = extender
 	state.Text = "                                                                                                                "
 	state.Assign = []rune{' ', ' '}
+	state.Digits = make([]gomcu.Char, 10)
 	state.FaderLevels = append(state.FaderLevels, 0, 0, 0, 0, 0, 0, 0, 0, 0)
 	state.MeterLevels = append(state.MeterLevels, 0, 0, 0, 0, 0, 0, 0, 0, 0)
 	state.FaderLevelsBuffered = append(state.FaderLevelsBuffered, 0, 0, 0, 0, 0, 0, 0, 0, 0)
 	state.FaderTouch = []bool{false, false, false, false, false, false, false, false, false}
 	now := time.Now()
 	state.FaderTouchTimeout = []time.Time{now, now, now, now, now, now, now, now, now}
-	state.LedStates = make(map[byte]bool)
+	state.LedStates = make(map[byte]gomcu.State)
 	state.VPotLedStates = make(map[byte]byte)
 	return &state
 }
@@ -54,7 +63,7 @@ func (m *McuState) UpdateTouch() {
 			if since.Milliseconds() > 300 {
 				m.FaderTouch[i] = false
 				// sends if not already same
-				m.SetFaderLevel(byte(i), level)
+				m.SetFaderPosition(byte(i), level)
 			}
 		}
 	}
@@ -63,25 +72,42 @@ func (m *McuState) UpdateTouch() {
 // SetFaderTouched sets the fader touch state and sends the buffered value
 // if the touch has ended
 func (m *McuState) SetFaderTouched(fader byte, touched bool) {
-	state.FaderTouch[fader] = touched
+	m.FaderTouch[fader] = touched
 	if !touched {
-		m.SetFaderLevel(fader, m.FaderLevelsBuffered[fader])
-	} else if config.Config.McuFaders.SimulateTouch {
-		state.FaderTouchTimeout[fader] = time.Now()
+		m.SetFaderPosition(fader, m.FaderLevelsBuffered[fader])
+	} else if config.Get().McuFaders.SimulateTouch {
+		m.FaderTouchTimeout[fader] = time.Now()
 	}
 }
 
 // SetFaderLevel sets the fader level and sends the value to the hardware
 // fader if it has changed
 func (m *McuState) SetFaderLevel(fader byte, level float64) {
-	m.FaderLevelsBuffered[fader] = level
-	newLevel := FaderFloatToInt(level)
+	m.SetFaderPosition(fader, FaderFloatToInt(level))
+}
+
+// SetMasterFader sets the master fader to a linear position between
+// 0 (bottom) and 1 (top)
+func (m *McuState) SetMasterFader(position float64) {
+	m.SetFaderLinear(byte(gomcu.Master), position)
+}
+
+// SetFaderLinear sets a fader to a linear position between
+// 0 (bottom) and 1 (top)
+func (m *McuState) SetFaderLinear(fader byte, position float64) {
+	m.SetFaderPosition(fader, int16(position*gomcu.FaderMax))
+}
+
+// SetFaderPosition sets the raw fader position and sends the value to the
+// hardware fader if it has changed and the fader is not touched
+func (m *McuState) SetFaderPosition(fader byte, newLevel int16) {
+	m.FaderLevelsBuffered[fader] = newLevel
 	if !m.FaderTouch[fader] {
 		if m.FaderLevels[fader] != newLevel {
 			m.FaderLevels[fader] = newLevel
 			channel := gomcu.Channel(fader)
 			x := []midi.Message{gomcu.SetFaderPos(channel, uint16(newLevel))}
-			sendMidi(x)
+			m.sendMidi(x)
 			if m.Debug {
 				log.Print(x)
 			}
@@ -125,6 +151,14 @@ func (m *McuState) SetSelectState(fader byte, state bool) {
 	}
 }
 
+// SetTallyState lights the select led of a fader for the program
+// and the rec led for the preview
+func (m *McuState) SetTallyState(fader byte, program bool, preview bool) {
+	m.SendLed(byte(gomcu.Select1)+fader, program)
+	m.SendLed(byte(gomcu.Rec1)+fader, preview)
+	m.SendLed(byte(gomcu.Solo1)+fader, false)
+}
+
 // SetAssignMode sets the assign mode and lights up
 // the assign buttons accordingly
 func (m *McuState) SetAssignMode(number byte) {
@@ -141,19 +175,33 @@ func (m *McuState) SetTrackEnabledState(track byte, state bool) {
 	m.SendLed(num, state)
 }
 
-// SendLed checks if the led state has changed and sends the
-// message to the hardware if it has changed
+// SendLed sets a led on or off for a built-in function, leds of the main unit
+// are left alone if the led or the button is mapped in the config
 func (m *McuState) SendLed(num byte, state bool) {
-	if m.LedStates[num] != state {
-		m.LedStates[num] = state
-		var mstate gomcu.State
-		if state {
-			mstate = gomcu.StateOn
-		} else {
-			mstate = gomcu.StateOff
-		}
+	if m.IsMapped(num) {
+		return
+	}
+	if state {
+		m.SendLedState(num, gomcu.StateOn)
+	} else {
+		m.SendLedState(num, gomcu.StateOff)
+	}
+}
+
+// IsMapped checks if the led is mapped to a state or action in the config,
+// the builtin functions don't light it then
+func (m *McuState) IsMapped(num byte) bool {
+	key := config.SwitchKey(gomcu.Switch(num))
+	return !m.Extender && (config.Get().LedMap[key] != "" || config.Get().ButtonMap[key] != "")
+}
+
+// SendLedState checks if the led state (off, on, blinking) has changed
+// and sends the message to the hardware if it has changed
+func (m *McuState) SendLedState(num byte, mstate gomcu.State) {
+	if m.LedStates[num] != mstate {
+		m.LedStates[num] = mstate
 		x := []midi.Message{gomcu.SetLED(gomcu.Switch(num), mstate)}
-		sendMidi(x)
+		m.sendMidi(x)
 		if m.Debug {
 			log.Print(x)
 		}
@@ -164,7 +212,7 @@ func (m *McuState) SendLed(num byte, state bool) {
 func (m *McuState) SetAssignText(text []rune) {
 	if m.Assign[0] != text[0] || m.Assign[1] != text[1] {
 		x := []midi.Message{gomcu.SetDigit(gomcu.AssignLeft, gomcu.Char(text[0])), gomcu.SetDigit(gomcu.AssignRight, gomcu.Char(text[1]))}
-		sendMidi(x)
+		m.sendMidi(x)
 		m.Assign = text
 		if m.Debug {
 			log.Print(x)
@@ -179,17 +227,45 @@ func (m *McuState) SetDisplayText(text string) {
 	} else {
 		text = fmt.Sprintf("%-10s", text)
 	}
-	if m.Display != text {
-		m.Display = text
-		x := []midi.Message{}
-		x = append(x, gomcu.SetTimeDisplay(text)...)
-		sendMidi(x)
+	digits := make([]gomcu.Char, 10)
+	for i, char := range []byte(strings.ToUpper(text)) {
+		digits[i] = gomcu.Char(char)
+	}
+	m.SetDisplayDigits(digits)
+}
+
+// SetDisplayDigits sets the 10 digits of the display (LED) from left to right,
+// only the digits that changed are sent
+func (m *McuState) SetDisplayDigits(digits []gomcu.Char) {
+	x := []midi.Message{}
+	for i := 0; i < len(m.Digits) && i < len(digits); i++ {
+		if m.Digits[i] != digits[i] {
+			m.Digits[i] = digits[i]
+			x = append(x, gomcu.SetDigit(gomcu.HundredHour-gomcu.Digit(i), digits[i]))
+		}
+	}
+	if len(x) > 0 {
+		m.sendMidi(x)
 		if m.Debug {
 			log.Print(x)
 		}
 	}
 }
 
+// ShowNotice shows a text on the whole upper row of the LCD,
+// the channel texts are kept and shown again by RestoreText
+func (m *McuState) ShowNotice(text string) {
+	m.Notice = true
+	text = fmt.Sprintf("%-56.56s", text)
+	m.sendMidi([]midi.Message{gomcu.SetLCD(0, text)})
+}
+
+// RestoreText shows the channel texts on the upper row of the LCD again
+func (m *McuState) RestoreText() {
+	m.Notice = false
+	m.sendMidi([]midi.Message{gomcu.SetLCD(0, m.Text[0:56])})
+}
+
 // SetChannelText sets the text above the fader channel strip (LCD)
 // the text is automatically shortened to 6 characters
 func (m *McuState) SetChannelText(fader byte, text string, lower bool) {
@@ -200,8 +276,17 @@ func (m *McuState) SetChannelText(fader byte, text string, lower bool) {
 	text = ShortenText(text)
 	if m.Text[idx:idx+6] != text {
 		m.Text = fmt.Sprintf("%s%s%s", m.Text[0:idx], text, m.Text[idx+6:])
-		x := []midi.Message{gomcu.SetLCD(idx, text)}
-		sendMidi(x)
+		// the upper row is sent by RestoreText after a notice
+		if m.Notice && !lower {
+			return
+		}
+		var x []midi.Message
+		if m.Extender {
+			x = []midi.Message{gomcu.SetLCDXT(idx, text)}
+		} else {
+			x = []midi.Message{gomcu.SetLCD(idx, text)}
+		}
+		m.sendMidi(x)
 		if m.Debug {
 			log.Print(x)
 		}
@@ -241,7 +326,7 @@ func (m *McuState) SetMeter(fader byte, value float64) {
 	if m.MeterLevels[fader] != outByte {
 		m.MeterLevels[fader] = outByte
 		x := []midi.Message{gomcu.SetMeter(gomcu.Channel(fader), gomcu.MeterLevel(outByte))}
-		sendMidi(x)
+		m.sendMidi(x)
 		if m.Debug {
 			log.Print(x)
 		}
@@ -254,9 +339,24 @@ func (m *McuState) SetVPotLed(fader byte, value byte) {
 	if m.VPotLedStates[fader] != value {
 		m.VPotLedStates[fader] = value
 		x := []midi.Message{gomcu.SetVPot(gomcu.Channel(fader), gomcu.VPotMode0, gomcu.VPotLED(value))}
-		sendMidi(x)
+		m.sendMidi(x)
 		if m.Debug {
 			log.Print(x)
 		}
 	}
 }
+
+// sendMidi sends a list of midi messages to the output of this state
+func (m *McuState) sendMidi(x []midi.Message) {
+	if m.Output == nil {
+		return
+	}
+	send, err := midi.SendTo(m.Output)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	for _, msg := range x {
+		send(msg)
+	}
+}
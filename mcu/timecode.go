@@ -0,0 +1,115 @@
+package mcu
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/normen/obs-mcu/gomcu"
+)
+
+const (
+	TimecodeScene  = "scene"
+	TimecodeStream = "stream"
+	TimecodeRecord = "record"
+	TimecodeClock  = "clock"
+)
+
+// the order in which the SMPTE/Beats button cycles the modes
+var timecodeModes = []string{TimecodeScene, TimecodeStream, TimecodeRecord, TimecodeClock}
+
+// Timecode stores what is shown on the timecode display,
+// it survives reconnects of the MCU
+type Timecode struct {
+	Mode      string
+	SceneName string
+	outputs   map[string]*outputTime
+}
+
+// running time of an obs output (stream or record)
+type outputTime struct {
+	active   bool
+	paused   bool
+	start    time.Time
+	duration time.Duration
+}
+
+// NewTimecode creates a new timecode display with the given mode
+func NewTimecode(mode string) *Timecode {
+	t := &Timecode{
+		Mode:      TimecodeScene,
+		SceneName: "OBS Studio",
+		outputs:   make(map[string]*outputTime),
+	}
+	for _, m := range timecodeModes {
+		if m == mode {
+			t.Mode = mode
+		}
+	}
+	return t
+}
+
+// NextMode switches to the next display mode
+func (t *Timecode) NextMode() {
+	for i, m := range timecodeModes {
+		if m == t.Mode {
+			t.Mode = timecodeModes[(i+1)%len(timecodeModes)]
+			return
+		}
+	}
+	t.Mode = TimecodeScene
+}
+
+// SetOutput sets the running time of an output as reported by obs
+func (t *Timecode) SetOutput(name string, active bool, paused bool, duration time.Duration) {
+	t.outputs[name] = &outputTime{
+		active:   active,
+		paused:   paused,
+		start:    time.Now().Add(-duration),
+		duration: duration,
+	}
+}
+
+// get the current running time of an output
+func (t *Timecode) getElapsed(name string) time.Duration {
+	if out, ok := t.outputs[name]; ok && out.active {
+		if out.paused {
+			return out.duration
+		}
+		return time.Since(out.start)
+	}
+	return 0
+}
+
+// Update shows the current mode on the display of the given state,
+// the SMPTE and Beats LEDs show the mode
+func (t *Timecode) Update(state *McuState) {
+	switch t.Mode {
+	case TimecodeStream, TimecodeRecord:
+		elapsed := t.getElapsed(t.Mode)
+		hours := int(elapsed.Hours())
+		minutes := int(elapsed.Minutes()) % 60
+		seconds := int(elapsed.Seconds()) % 60
+		state.SetDisplayDigits(timeDigits(hours, minutes, seconds))
+	case TimecodeClock:
+		now := time.Now()
+		state.SetDisplayDigits(timeDigits(now.Hour(), now.Minute(), now.Second()))
+	default:
+		state.SetDisplayText(t.SceneName)
+	}
+	state.SendLed(byte(gomcu.STMPELED), t.Mode == TimecodeStream || t.Mode == TimecodeClock)
+	state.SendLed(byte(gomcu.BeatsLED), t.Mode == TimecodeRecord || t.Mode == TimecodeClock)
+}
+
+// creates the display digits for a time, hours minutes and seconds
+// are shown on their positions and separated by dots
+func timeDigits(hours int, minutes int, seconds int) []gomcu.Char {
+	text := fmt.Sprintf("%3d%02d%02d   ", hours%1000, minutes, seconds)
+	digits := make([]gomcu.Char, len(text))
+	for i, char := range []byte(text) {
+		digits[i] = gomcu.Char(char)
+	}
+	// dots after the hours and minutes
+	digits[2] += gomcu.DigitDot
+	digits[4] += gomcu.DigitDot
+	return digits
+}
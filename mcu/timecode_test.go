@@ -0,0 +1,36 @@
+package mcu
+
+import (
+	"testing"
+
+	"github.com/normen/obs-mcu/gomcu"
+)
+
+func TestTimeDigits(t *testing.T) {
+	tests := []struct {
+		hours, minutes, seconds int
+		want                    string
+	}{
+		{0, 0, 0, "  00000   "},
+		{1, 2, 3, "  10203   "},
+		{12, 34, 56, " 123456   "},
+		{999, 59, 59, "9995959   "},
+		{1001, 0, 5, "  10005   "},
+	}
+	for _, test := range tests {
+		digits := timeDigits(test.hours, test.minutes, test.seconds)
+		if len(digits) != len(test.want) {
+			t.Fatalf("timeDigits(%d, %d, %d) has %d digits, want %d", test.hours, test.minutes, test.seconds, len(digits), len(test.want))
+		}
+		for i, char := range []byte(test.want) {
+			want := gomcu.Char(char)
+			// dots after the hours and minutes
+			if i == 2 || i == 4 {
+				want += gomcu.DigitDot
+			}
+			if digits[i] != want {
+				t.Errorf("timeDigits(%d, %d, %d) digit %d = %v, want %v", test.hours, test.minutes, test.seconds, i, digits[i], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package mcu
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/normen/obs-mcu/config"
+	"github.com/normen/obs-mcu/msg"
+)
+
+// ValidateButtons checks all button commands in the config,
+// returns the problems found and the buttons of all hotkeys used by KEY commands
+func ValidateButtons() ([]error, map[string]string) {
+	errs := []error{}
+	hotkeys := make(map[string]string)
+	for buttonName, command := range config.Get().ButtonMap {
+		if command == "" || command == config.Disabled {
+			continue
+		}
+		cmdType, cmdString, err := parseCommand(command)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("[mcu_buttons] %s: %v", buttonName, err))
+			continue
+		}
+		for _, keys := range commandHotkeys(cmdType, cmdString) {
+			for _, key := range strings.Split(keys, ",") {
+				hotkeys[key] = buttonName
+			}
+		}
+	}
+	return errs, hotkeys
+}
+
+// get the hotkeys of a valid command, the KEY steps of a macro included
+func commandHotkeys(cmdType string, cmdString string) []string {
+	switch cmdType {
+	case "KEY":
+		return []string{cmdString}
+	case "MACRO":
+		keys := []string{}
+		steps, _ := parseMacro(cmdString)
+		for _, step := range steps {
+			if step.Action == "KEY" {
+				keys = append(keys, step.Value)
+			}
+		}
+		return keys
+	}
+	return nil
+}
+
+// splits a command into its type and value, fails for unknown types
+func parseCommand(command string) (string, string, error) {
+	cmdType, cmdString, found := strings.Cut(command, ":")
+	if !found {
+		return "", "", fmt.Errorf("missing command type in %q", command)
+	}
+	switch cmdType {
+	case "KEY", "SCENE", "REQUEST", "MACRO", "STUDIO", "TRANSITION":
+	default:
+		return "", "", fmt.Errorf("unknown command type %q", cmdType)
+	}
+	if cmdString == "" {
+		return "", "", fmt.Errorf("missing value for %s", cmdType)
+	}
+	switch cmdType {
+	case "STUDIO":
+		if cmdString != msg.StudioToggle && cmdString != msg.StudioTransition {
+			return "", "", fmt.Errorf("unknown studio mode action %q", cmdString)
+		}
+	case "REQUEST":
+		if _, _, err := parseRequest(cmdString); err != nil {
+			return "", "", err
+		}
+	case "MACRO":
+		if _, err := parseMacro(cmdString); err != nil {
+			return "", "", err
+		}
+	}
+	return cmdType, cmdString, nil
+}
+
+// splits a request command into the request type and its json data
+func parseRequest(cmdString string) (string, json.RawMessage, error) {
+	requestType, requestData, _ := strings.Cut(strings.TrimSpace(cmdString), " ")
+	requestData = strings.TrimSpace(requestData)
+	if requestData != "" && !json.Valid([]byte(requestData)) {
+		return "", nil, fmt.Errorf("invalid json data for %s: %s", requestType, requestData)
+	}
+	return requestType, json.RawMessage(requestData), nil
+}
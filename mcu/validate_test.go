@@ -0,0 +1,57 @@
+package mcu
+
+import "testing"
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		command  string
+		cmdType  string
+		cmdValue string
+	}{
+		{"KEY:OBSBasic.StartStreaming", "KEY", "OBSBasic.StartStreaming"},
+		{"SCENE:Camera: Wide", "SCENE", "Camera: Wide"},
+		{"STUDIO:toggle", "STUDIO", "toggle"},
+		{"STUDIO:transition", "STUDIO", "transition"},
+		{"TRANSITION:Fade", "TRANSITION", "Fade"},
+		{`REQUEST:SetInputSettings {"inputSettings": {"color": "#ff0000"}}`, "REQUEST", `SetInputSettings {"inputSettings": {"color": "#ff0000"}}`},
+		{"MACRO:SCENE:Main | WAIT:100", "MACRO", "SCENE:Main | WAIT:100"},
+	}
+	for _, test := range tests {
+		cmdType, cmdValue, err := parseCommand(test.command)
+		if err != nil {
+			t.Errorf("parseCommand(%q) failed: %v", test.command, err)
+			continue
+		}
+		if cmdType != test.cmdType || cmdValue != test.cmdValue {
+			t.Errorf("parseCommand(%q) = %q, %q, want %q, %q", test.command, cmdType, cmdValue, test.cmdType, test.cmdValue)
+		}
+	}
+}
+
+func TestParseCommandErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"Main",
+		"KEY:",
+		"key:OBSBasic.StartStreaming",
+		"JUMP:Main",
+		"STUDIO:on",
+		`REQUEST:SetInputMute {"inputName"`,
+		"MACRO:SCENE:Main | WAIT:soon",
+	}
+	for _, command := range tests {
+		if _, _, err := parseCommand(command); err == nil {
+			t.Errorf("parseCommand(%q) should fail", command)
+		}
+	}
+}
+
+func TestCommandHotkeys(t *testing.T) {
+	keys := commandHotkeys("MACRO", "KEY:a,b | SCENE:Main | KEY:c")
+	if len(keys) != 2 || keys[0] != "a,b" || keys[1] != "c" {
+		t.Errorf("commandHotkeys of a macro = %q, want [a,b c]", keys)
+	}
+	if keys := commandHotkeys("SCENE", "Main"); len(keys) != 0 {
+		t.Errorf("commandHotkeys of a scene = %q, want none", keys)
+	}
+}
@@ -1,5 +1,25 @@
 package msg
 
+import (
+	"encoding/json"
+	"time"
+)
+
+// state of a led in a LedMessage
+type LedState byte
+
+const (
+	LedOff LedState = iota
+	LedOn
+	LedBlink
+)
+
+// actions of a StudioModeMessage
+const (
+	StudioToggle     = "toggle"
+	StudioTransition = "transition"
+)
+
 // user -> obs runloop
 type MidiInputSetting struct {
 	PortName string
@@ -10,6 +30,16 @@ type MidiOutputSetting struct {
 	PortName string
 }
 
+// user -> systray
+type ProfileSetting struct {
+	Name string
+}
+
+// config -> obs -> mackie
+// sent after the config file was reloaded
+type ConfigReloadMessage struct {
+}
+
 // obs <- mackie
 type UpdateRequest struct {
 }
@@ -19,9 +49,12 @@ type KeyMessage struct {
 	HotkeyName string
 }
 
+// moves the strips by the change amount, pages move
+// by the number of strips of the whole surface
 // obs <- mackie
 type BankMessage struct {
 	ChangeAmount int
+	Page         bool
 }
 
 // obs <- mackie
@@ -30,6 +63,23 @@ type VPotChangeMessage struct {
 	ChangeAmount int
 }
 
+// obs <- mackie
+type NameValueMessage struct {
+}
+
+// obs <- mackie
+type FlipMessage struct {
+}
+
+// obs <- mackie
+type JogMessage struct {
+	ChangeAmount int
+}
+
+// obs <- mackie
+type ScrubMessage struct {
+}
+
 // obs <- mackie
 type VPotButtonMessage struct {
 	FaderNumber byte
@@ -59,9 +109,30 @@ type TrackEnableMessage struct {
 }
 
 // obs <-> mackie
+// the mackie also sends the linear position, 0 is bottom and 1 is top
 type FaderMessage struct {
 	FaderNumber byte
 	FaderValue  float64
+	Position    float64
+}
+
+// obs -> mackie
+// sets a linear fader position, used when flipped
+type FaderPositionMessage struct {
+	FaderNumber byte
+	Position    float64
+}
+
+// obs <-> mackie
+// position is linear, 0 is bottom and 1 is top
+type MasterFaderMessage struct {
+	Position float64
+}
+
+// obs <- mackie
+// the master fader was touched or let go
+type MasterTouchMessage struct {
+	Pressed bool
 }
 
 // obs <-> mackie
@@ -70,12 +141,79 @@ type MonitorTypeMessage struct {
 	MonitorType string
 }
 
+// one step of a macro, the action is one of KEY, SCENE, REQUEST,
+// MUTE, UNMUTE, WAIT or FADE
+type MacroStep struct {
+	Action   string
+	Value    string
+	Data     json.RawMessage
+	Db       float64
+	Duration time.Duration
+}
+
+// obs <- mackie
+// a running macro with the same name is cancelled instead
+type MacroMessage struct {
+	Name  string
+	Steps []MacroStep
+}
+
+// obs <- mackie
+// the request data is a json object, may be empty
+type RequestMessage struct {
+	RequestType string
+	RequestData json.RawMessage
+}
+
+// obs <- mackie
+type SceneMessage struct {
+	SceneName string
+}
+
+// toggles studio mode or triggers the studio mode transition
+// obs <- mackie
+type StudioModeMessage struct {
+	Action string
+}
+
+// sets the current scene transition
+// obs <- mackie
+type TransitionMessage struct {
+	TransitionName string
+}
+
 // obs -> mackie
+// the led name is the config key of the switch, e.g. "name_value",
+// builtin leds are not sent when the switch is mapped in the config
 type LedMessage struct {
 	LedName  string
-	LedState bool
+	LedState LedState
+	Builtin  bool
+}
+
+// lights the select led of a strip for the program scene
+// and the rec led for the preview scene
+// obs -> mackie
+type TallyMessage struct {
+	FaderNumber byte
+	Program     bool
+	Preview     bool
 }
 
+// shows a text on the upper lcd row of the main unit for a moment
+// obs -> mackie
+type NoticeMessage struct {
+	Text string
+}
+
+// restores the upper lcd row after a notice
+// internal
+type NoticeEndMessage struct{}
+
+// switches to the next channel visibility mode
+// obs <- mackie
+type VisibilityMessage struct{}
+
 // obs -> mackie
 type ChannelTextMessage struct {
 	FaderNumber byte
@@ -88,6 +226,15 @@ type DisplayTextMessage struct {
 	Text string
 }
 
+// obs -> mackie
+// running time of an output, output is "stream" or "record"
+type OutputTimeMessage struct {
+	Output   string
+	Active   bool
+	Paused   bool
+	Duration time.Duration
+}
+
 // obs -> mackie
 type VPotLedMessage struct {
 	FaderNumber byte
@@ -107,12 +254,24 @@ type MeterMessage struct {
 
 // internal mcu message
 type RawFaderMessage struct {
+	Device      int
 	FaderNumber byte
 	FaderValue  int16
 }
 
+// internal mcu message
+type ModifierMessage struct {
+	Modifier byte
+	Pressed  bool
+}
+
+// internal mcu message
+type TimecodeModeMessage struct {
+}
+
 // internal mcu message
 type RawFaderTouchMessage struct {
+	Device      int
 	FaderNumber byte
 	Pressed     bool
 }
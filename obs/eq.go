@@ -0,0 +1,171 @@
+package obs
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/andreykaipov/goobs/api/requests/filters"
+	"github.com/normen/obs-mcu/msg"
+)
+
+// kind and name of the obs 3-band equalizer filter
+const (
+	eqFilterKind = "basic_eq_filter"
+	eqFilterName = "3-Band Equalizer"
+)
+
+// gain range and vpot step of the eq bands in dB
+const (
+	eqGainMax  = 20.0
+	eqGainStep = 0.5
+)
+
+// the settings of the eq bands and their names on the lcd
+var eqBands = []string{"low", "mid", "high"}
+var eqBandNames = []string{"Low", "Mid", "High"}
+
+// The 3-band equalizer of the selected channel shown on the first strips in EQ mode.
+// The VPots change the gain of the bands, the VPot button resets the gain
+// or creates the filter if the channel has none.
+type Equalizer struct {
+	SourceName string
+	// the name of the eq filter, empty if the channel has none
+	FilterName string
+	gains      map[string]float64
+}
+
+// create a new equalizer
+func NewEqualizer() *Equalizer {
+	return &Equalizer{
+		gains: make(map[string]float64),
+	}
+}
+
+// gets the eq filter of the selected channel from obs
+func (q *Equalizer) Update() {
+	q.SourceName = channels.SelectedChannel
+	q.FilterName = ""
+	q.gains = make(map[string]float64)
+	if q.SourceName != "" {
+		list, err := client.Filters.GetSourceFilterList(&filters.GetSourceFilterListParams{SourceName: &q.SourceName})
+		if err != nil {
+			log.Print(err)
+		} else {
+			for _, filter := range list.Filters {
+				if filter.FilterKind == eqFilterKind {
+					q.FilterName = filter.FilterName
+					q.setGains(filter.FilterSettings)
+					break
+				}
+			}
+		}
+	}
+	channels.sync()
+}
+
+// sets the gains from the filter settings, obs only sends the values
+// that differ from the default of 0dB
+func (q *Equalizer) setGains(settings map[string]any) {
+	for _, band := range eqBands {
+		gain, _ := settings[band].(float64)
+		q.gains[band] = gain
+	}
+}
+
+// set the settings of a filter when obs changed them
+func (q *Equalizer) SetSettings(sourceName string, filterName string, settings map[string]any) {
+	if sourceName != q.SourceName || filterName != q.FilterName || q.FilterName == "" {
+		return
+	}
+	q.setGains(settings)
+	if channels.AssignMode == ModeEQ {
+		channels.sync()
+	}
+}
+
+// updates the eq when the filters of a source changed
+func (q *Equalizer) FiltersChanged(sourceName string) {
+	if sourceName == q.SourceName && channels.AssignMode == ModeEQ {
+		q.Update()
+	}
+}
+
+// creates the eq filter on the selected channel
+func (q *Equalizer) create() {
+	kind := eqFilterKind
+	name := eqFilterName
+	_, err := client.Filters.CreateSourceFilter(&filters.CreateSourceFilterParams{SourceName: &q.SourceName, FilterName: &name, FilterKind: &kind})
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// sets the gain of a band in dB
+func (q *Equalizer) setGain(band string, gain float64) {
+	gain = math.Max(math.Min(gain, eqGainMax), -eqGainMax)
+	overlay := true
+	_, err := client.Filters.SetSourceFilterSettings(&filters.SetSourceFilterSettingsParams{SourceName: &q.SourceName, FilterName: &q.FilterName, FilterSettings: map[string]any{band: gain}, Overlay: &overlay})
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	// set locally so fast turns add up before obs sends the change
+	q.gains[band] = gain
+}
+
+// sends the bands to the mcu, called from ChannelList.SyncMcu after the strips were cleared
+func (q *Equalizer) SyncMcu() {
+	if q.SourceName == "" {
+		return
+	}
+	for i, band := range eqBands {
+		text := "add EQ"
+		led := byte(0x00)
+		if q.FilterName != "" {
+			gain := q.gains[band]
+			text = fmt.Sprintf("%.1f", gain)
+			led = byte((gain+eqGainMax)/(2*eqGainMax)*10 + 1)
+		}
+		fromObs <- msg.ChannelTextMessage{
+			FaderNumber: byte(i),
+			Text:        eqBandNames[i],
+		}
+		fromObs <- msg.ChannelTextMessage{
+			FaderNumber: byte(i),
+			Lower:       true,
+			Text:        text,
+		}
+		fromObs <- msg.VPotLedMessage{
+			FaderNumber: byte(i),
+			LedState:    led,
+		}
+	}
+}
+
+// handles the strip messages of the mcu in EQ mode,
+// returns true if the message was used
+func (q *Equalizer) processMcuMessage(message interface{}) bool {
+	switch e := message.(type) {
+	case msg.VPotButtonMessage:
+		if int(e.FaderNumber) >= len(eqBands) || q.SourceName == "" {
+			break
+		}
+		if q.FilterName == "" {
+			q.create()
+		} else {
+			q.setGain(eqBands[e.FaderNumber], 0)
+		}
+	case msg.VPotChangeMessage:
+		if int(e.FaderNumber) >= len(eqBands) || q.FilterName == "" {
+			break
+		}
+		band := eqBands[e.FaderNumber]
+		q.setGain(band, q.gains[band]+float64(e.ChangeAmount)*eqGainStep)
+	case msg.BankMessage, msg.FaderMessage, msg.MuteMessage, msg.SelectMessage, msg.MonitorTypeMessage:
+		// the strips have no channels in EQ mode
+	default:
+		return false
+	}
+	return true
+}
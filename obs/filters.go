@@ -0,0 +1,336 @@
+package obs
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+
+	"github.com/andreykaipov/goobs/api/requests/filters"
+	"github.com/andreykaipov/goobs/api/typedefs"
+	"github.com/normen/obs-mcu/msg"
+)
+
+// one filter of a source
+type Filter struct {
+	Name    string
+	Kind    string
+	Enabled bool
+}
+
+// value range of a filter setting
+type settingRange struct {
+	Min float64
+	Max float64
+}
+
+// the ranges of the settings of the obs audio filters by filter kind,
+// obs-websocket can't report them so other settings are not limited
+var filterRanges = map[string]map[string]settingRange{
+	"gain_filter": {
+		"db": {-30, 30},
+	},
+	"noise_suppress_filter": {
+		"suppress_level": {-60, 0},
+	},
+	"noise_gate_filter": {
+		"open_threshold":  {-96, 0},
+		"close_threshold": {-96, 0},
+		"attack_time":     {0, 10000},
+		"hold_time":       {0, 10000},
+		"release_time":    {0, 10000},
+	},
+	"compressor_filter": {
+		"ratio":        {1, 32},
+		"threshold":    {-60, 0},
+		"attack_time":  {1, 500},
+		"release_time": {1, 1000},
+		"output_gain":  {-32, 32},
+	},
+	"limiter_filter": {
+		"threshold":    {-60, 0},
+		"release_time": {1, 1000},
+	},
+	"expander_filter": {
+		"ratio":        {1, 20},
+		"threshold":    {-60, 0},
+		"attack_time":  {1, 100},
+		"release_time": {1, 1000},
+		"output_gain":  {-32, 32},
+	},
+	"basic_eq_filter": {
+		"low":  {-eqGainMax, eqGainMax},
+		"mid":  {-eqGainMax, eqGainMax},
+		"high": {-eqGainMax, eqGainMax},
+	},
+}
+
+// The filters of the selected channel shown on the strips in plugin mode.
+// Mute toggles a filter, the VPot button opens the numeric settings of a filter
+// which are then shown on the strips and changed with the VPots.
+type FilterList struct {
+	SourceName string
+	filters    []Filter
+	First      int
+	// the filter whose settings are shown, empty for the filter list
+	OpenFilter string
+	settings   []string
+	defaults   map[string]any
+	values     map[string]float64
+}
+
+// create a new filter list
+func NewFilterList() *FilterList {
+	return &FilterList{
+		values: make(map[string]float64),
+	}
+}
+
+// gets the filters of the selected channel from obs
+func (f *FilterList) Update() {
+	if f.SourceName != channels.SelectedChannel {
+		f.SourceName = channels.SelectedChannel
+		f.First = 0
+		f.OpenFilter = ""
+	}
+	f.filters = []Filter{}
+	if f.SourceName != "" {
+		list, err := client.Filters.GetSourceFilterList(&filters.GetSourceFilterListParams{SourceName: &f.SourceName})
+		if err != nil {
+			log.Print(err)
+		} else {
+			f.setFilters(list.Filters)
+		}
+	}
+	if f.OpenFilter != "" && f.getFilter(f.OpenFilter) == nil {
+		f.OpenFilter = ""
+	}
+	if f.OpenFilter != "" {
+		f.updateSettings()
+	}
+	channels.sync()
+}
+
+// sets the filters from a filter list of obs
+func (f *FilterList) setFilters(list []*typedefs.Filter) {
+	f.filters = []Filter{}
+	for _, filter := range list {
+		f.filters = append(f.filters, Filter{
+			Name:    filter.FilterName,
+			Kind:    filter.FilterKind,
+			Enabled: filter.FilterEnabled,
+		})
+	}
+}
+
+// get a filter of the list by its name, nil if not found
+func (f *FilterList) getFilter(name string) *Filter {
+	for i := range f.filters {
+		if f.filters[i].Name == name {
+			return &f.filters[i]
+		}
+	}
+	return nil
+}
+
+// gets the settings of the open filter from obs,
+// only the numeric settings can be changed
+func (f *FilterList) updateSettings() {
+	filter := f.getFilter(f.OpenFilter)
+	if filter == nil {
+		return
+	}
+	defaults, err := client.Filters.GetSourceFilterDefaultSettings(&filters.GetSourceFilterDefaultSettingsParams{FilterKind: &filter.Kind})
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	resp, err := client.Filters.GetSourceFilter(&filters.GetSourceFilterParams{SourceName: &f.SourceName, FilterName: &f.OpenFilter})
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	f.defaults = defaults.DefaultFilterSettings
+	f.settings = []string{}
+	for name, value := range f.defaults {
+		if _, ok := value.(float64); ok {
+			f.settings = append(f.settings, name)
+		}
+	}
+	slices.Sort(f.settings)
+	f.setValues(resp.FilterSettings)
+}
+
+// sets the values of the open filter, obs only sends the values
+// that differ from the defaults
+func (f *FilterList) setValues(settings map[string]any) {
+	f.values = make(map[string]float64)
+	for _, name := range f.settings {
+		value, ok := settings[name].(float64)
+		if !ok {
+			value, _ = f.defaults[name].(float64)
+		}
+		f.values[name] = value
+	}
+}
+
+// get the step of a setting for one VPot click,
+// settings with an integer default change in steps of 1
+func (f *FilterList) getStep(name string) float64 {
+	value, _ := f.defaults[name].(float64)
+	if value == math.Trunc(value) {
+		return 1
+	}
+	return 0.1
+}
+
+// limit a setting of the open filter to its range
+func (f *FilterList) clamp(name string, value float64) float64 {
+	if filter := f.getFilter(f.OpenFilter); filter != nil {
+		if r, ok := filterRanges[filter.Kind][name]; ok {
+			return math.Max(math.Min(value, r.Max), r.Min)
+		}
+	}
+	return value
+}
+
+// set the enabled state of a filter when obs changed it
+func (f *FilterList) SetEnabled(sourceName string, filterName string, enabled bool) {
+	if sourceName != f.SourceName {
+		return
+	}
+	if filter := f.getFilter(filterName); filter != nil {
+		filter.Enabled = enabled
+		f.sync()
+	}
+}
+
+// set the settings of a filter when obs changed them
+func (f *FilterList) SetSettings(sourceName string, filterName string, settings map[string]any) {
+	if sourceName != f.SourceName || filterName != f.OpenFilter {
+		return
+	}
+	f.setValues(settings)
+	f.sync()
+}
+
+// updates the list when the filters of a source changed
+func (f *FilterList) FiltersChanged(sourceName string) {
+	if sourceName == f.SourceName && channels.AssignMode == ModePlugin {
+		f.Update()
+	}
+}
+
+// syncs the strips when the plugin mode is active
+func (f *FilterList) sync() {
+	if channels.AssignMode == ModePlugin {
+		channels.sync()
+	}
+}
+
+// get the number of entries that can be shown
+func (f *FilterList) count() int {
+	if f.OpenFilter != "" {
+		return len(f.settings)
+	}
+	return len(f.filters)
+}
+
+// change the first filter or setting shown on the mcu
+func (f *FilterList) ChangeBank(amount int) {
+	f.First = max(min(f.First+amount, f.count()-1), 0)
+	channels.sync()
+}
+
+// sends the filters or the settings of the open filter to the mcu,
+// called from ChannelList.SyncMcu after the strips were cleared
+func (f *FilterList) SyncMcu() {
+	for i := 0; i < stripCount(); i++ {
+		idx := f.First + i
+		var upper, lower string
+		bypassed := false
+		if f.OpenFilter != "" && idx < len(f.settings) {
+			name := f.settings[idx]
+			upper = name
+			if f.getStep(name) == 1 {
+				lower = fmt.Sprintf("%.0f", f.values[name])
+			} else {
+				lower = fmt.Sprintf("%.1f", f.values[name])
+			}
+		} else if f.OpenFilter == "" && idx < len(f.filters) {
+			upper = f.filters[idx].Name
+			lower = "on"
+			if !f.filters[idx].Enabled {
+				lower = "off"
+				bypassed = true
+			}
+		}
+		fromObs <- msg.ChannelTextMessage{
+			FaderNumber: byte(i),
+			Text:        upper,
+		}
+		fromObs <- msg.ChannelTextMessage{
+			FaderNumber: byte(i),
+			Lower:       true,
+			Text:        lower,
+		}
+		fromObs <- msg.MuteMessage{
+			FaderNumber: byte(i),
+			Value:       bypassed,
+		}
+	}
+	asgn := []rune{'0' + rune((f.First+1)/10%10), '0' + rune((f.First+1)%10)}
+	fromObs <- msg.AssignLEDMessage{
+		Characters: asgn,
+	}
+}
+
+// handles the strip messages of the mcu in plugin mode,
+// returns true if the message was used
+func (f *FilterList) processMcuMessage(message interface{}) bool {
+	switch e := message.(type) {
+	case msg.BankMessage:
+		f.ChangeBank(bankAmount(e, stripCount()))
+	case msg.MuteMessage:
+		idx := f.First + int(e.FaderNumber)
+		if f.OpenFilter == "" && idx < len(f.filters) {
+			enabled := !f.filters[idx].Enabled
+			_, err := client.Filters.SetSourceFilterEnabled(&filters.SetSourceFilterEnabledParams{SourceName: &f.SourceName, FilterName: &f.filters[idx].Name, FilterEnabled: &enabled})
+			if err != nil {
+				log.Print(err)
+			}
+		}
+	case msg.VPotButtonMessage:
+		idx := f.First + int(e.FaderNumber)
+		if f.OpenFilter != "" {
+			// any vpot button returns to the filter list
+			f.First = max(slices.IndexFunc(f.filters, func(filter Filter) bool { return filter.Name == f.OpenFilter }), 0)
+			f.OpenFilter = ""
+			channels.sync()
+		} else if idx < len(f.filters) {
+			f.OpenFilter = f.filters[idx].Name
+			f.First = 0
+			f.updateSettings()
+			channels.sync()
+		}
+	case msg.VPotChangeMessage:
+		idx := f.First + int(e.FaderNumber)
+		if f.OpenFilter != "" && idx < len(f.settings) {
+			name := f.settings[idx]
+			value := f.clamp(name, f.values[name]+float64(e.ChangeAmount)*f.getStep(name))
+			overlay := true
+			_, err := client.Filters.SetSourceFilterSettings(&filters.SetSourceFilterSettingsParams{SourceName: &f.SourceName, FilterName: &f.OpenFilter, FilterSettings: map[string]any{name: value}, Overlay: &overlay})
+			if err != nil {
+				log.Print(err)
+			} else {
+				// set locally so fast turns add up before obs sends the change
+				f.values[name] = value
+			}
+		}
+	case msg.FaderMessage, msg.SelectMessage, msg.MonitorTypeMessage:
+		// the strips have no channels in plugin mode
+	default:
+		return false
+	}
+	return true
+}
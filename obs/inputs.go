@@ -3,28 +3,36 @@ package obs
 import (
 	"fmt"
 	"log"
-	"sort"
+	"math"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/andreykaipov/goobs/api/requests/inputs"
-	"github.com/andreykaipov/goobs/api/requests/sceneitems"
 	"github.com/normen/obs-mcu/config"
 	"github.com/normen/obs-mcu/msg"
 )
 
+// sync offset range of a flipped fader in ms, obs allows up to 20000ms
+// but the range is limited to keep a usable resolution on the fader
+const (
+	flipDelayMin = -950.0
+	flipDelayMax = 1000.0
+)
+
 const (
 	ModeDelay byte = iota
-	Mode_2
+	ModeScene
 	ModePan
-	Mode_4
-	Mode_5
-	Mode_6
+	ModePlugin
+	ModeEQ
+	ModeTransition
 )
 
 // one source in obs that can be controlled if visible
 type Channel struct {
 	Name        string
+	Kind        string
 	Visible     bool
 	Muted       bool
 	Pan         float64
@@ -50,15 +58,77 @@ type ChannelList struct {
 	inputs          map[string]*Channel
 	FirstChannel    int
 	AssignMode      byte
+	ShowValues      bool
+	Flip            bool
 	SelectedChannel string
-	syncRetry       *time.Timer
+	VisibilityMode  string
+	// the visibility mode of the config, applied when it changes
+	configVisibility string
+	special          map[string]bool
+	rules            *ChannelRules
+	syncRetry        *time.Timer
+}
+
+// number of strips on the surface including extenders
+func stripCount() int {
+	return 8 * (1 + config.Get().Midi.ExtenderCount())
 }
 
 // create a new channel list
 func NewChannelList() *ChannelList {
-	return &ChannelList{
-		inputs: make(map[string]*Channel),
+	l := &ChannelList{
+		inputs:           make(map[string]*Channel),
+		special:          make(map[string]bool),
+		VisibilityMode:   config.Get().McuChannels.Visibility,
+		configVisibility: config.Get().McuChannels.Visibility,
+	}
+	l.loadRules()
+	return l
+}
+
+// LoadConfig reads the channel rules from the config again, the visibility mode
+// is only set if it was changed in the config so the mode picked on the mcu stays
+func (l *ChannelList) LoadConfig() {
+	l.loadRules()
+	if l.configVisibility != config.Get().McuChannels.Visibility {
+		l.configVisibility = config.Get().McuChannels.Visibility
+		l.VisibilityMode = l.configVisibility
+	}
+	if connected {
+		l.UpdateVisible()
+	} else {
+		l.sync()
+	}
+}
+
+// reads the channel rules from the config, errors are logged
+func (l *ChannelList) loadRules() {
+	rules, errs := loadChannelRules()
+	for _, err := range errs {
+		log.Print(err)
+	}
+	l.rules = rules
+}
+
+// get the number of strips a bank message moves,
+// a page moves the given page size
+func bankAmount(e msg.BankMessage, pageSize int) int {
+	if e.Page {
+		return e.ChangeAmount * pageSize
+	}
+	return e.ChangeAmount
+}
+
+// get the number of strips that scroll with the bank,
+// strips with a pinned channel stay in place
+func (l *ChannelList) pageSize() int {
+	size := stripCount()
+	for strip, name := range l.rules.pinned {
+		if _, ok := l.inputs[name]; ok && strip < stripCount() {
+			size--
+		}
 	}
+	return max(size, 1)
 }
 
 // change the first channel shown on the mcu
@@ -70,29 +140,56 @@ func (l *ChannelList) ChangeFaderBank(amount int) {
 	l.sync()
 }
 
-// create alphabetically sorted list of visible channels
+// check if the strips show the channels,
+// other assign modes like the scene list use the strips themselves
+func (l *ChannelList) ShowsChannels() bool {
+	return l.AssignMode == ModeDelay || l.AssignMode == ModePan || l.AssignMode == ModeTransition
+}
+
+// create list of the channels on the strips, pinned channels are always
+// on their strip, the other strips show the visible channels allowed
+// by the channel rules, sorted by the order list and then alphabetically.
+// Strips without a channel between pinned strips have an empty name,
+// the list is empty if the strips don't show the channels
 func (l *ChannelList) GetVisible() []Channel {
+	if !l.ShowsChannels() {
+		return []Channel{}
+	}
+	strips := make([]Channel, stripCount())
+	for strip, name := range l.rules.pinned {
+		if channel, ok := l.inputs[name]; ok && strip < len(strips) {
+			strips[strip] = *channel
+		}
+	}
 	var keys []string
 	var channels []Channel
 	for k := range l.inputs {
 		keys = append(keys, k)
 	}
-	sort.Strings(keys)
+	slices.SortFunc(keys, l.rules.Compare)
 	for _, k := range keys {
 		value, _ := l.inputs[k]
-		if value.Visible {
+		if value.Visible && l.rules.Allows(value) && !l.rules.IsPinned(k) {
 			channels = append(channels, *value)
 		}
 	}
+	// the bank only moves the strips that are not pinned
 	if len(channels) > l.FirstChannel {
-		vis := channels[l.FirstChannel:]
-		if len(vis) > 8 {
-			vis = vis[:8]
-		}
-		return vis
+		channels = channels[l.FirstChannel:]
 	} else {
-		return []Channel{}
+		channels = []Channel{}
 	}
+	for i := range strips {
+		if strips[i].Name == "" && len(channels) > 0 {
+			strips[i] = channels[0]
+			channels = channels[1:]
+		}
+	}
+	last := len(strips)
+	for last > 0 && strips[last-1].Name == "" {
+		last--
+	}
+	return strips[:last]
 }
 
 // get the name of a visible channel by its index on the mcu
@@ -108,6 +205,9 @@ func (l *ChannelList) GetVisibleName(index byte) string {
 // get the index of a visible channel by its name
 // returns -1 if not found
 func (l *ChannelList) GetVisibleNumber(name string) int {
+	if name == "" {
+		return -1
+	}
 	visible := l.GetVisible()
 	for idx, ch := range visible {
 		if ch.Name == name {
@@ -125,6 +225,7 @@ func (l *ChannelList) GetVisibleNumber(name string) int {
 func (l *ChannelList) AddChannel(name string) {
 	if _, ok := l.inputs[name]; !ok {
 		c := NewChannel(name)
+		c.Visible = l.VisibilityMode == VisibleAll
 		l.inputs[name] = c
 		l.getBaseInfos(name)
 	}
@@ -172,14 +273,9 @@ func (l *ChannelList) SetPan(name string, pan float64) {
 			if l.AssignMode == ModePan {
 				num := l.GetVisibleNumber(name)
 				if num != -1 {
-					fromObs <- msg.ChannelTextMessage{
-						FaderNumber: byte(num),
-						Lower:       true,
-						Text:        fmt.Sprintf("%.2f", pan-0.5),
-					}
-					fromObs <- msg.VPotLedMessage{
-						FaderNumber: byte(num),
-						LedState:    byte(pan*11.0 + 1),
+					l.sendAssign(byte(num), *channel)
+					if l.Flip {
+						l.sendFader(byte(num), *channel)
 					}
 				}
 			}
@@ -242,14 +338,9 @@ func (l *ChannelList) SetDelayMS(name string, delay float64) {
 			if l.AssignMode == ModeDelay {
 				num := l.GetVisibleNumber(name)
 				if num != -1 {
-					fromObs <- msg.ChannelTextMessage{
-						FaderNumber: byte(num),
-						Lower:       true,
-						Text:        fmt.Sprintf("%.0fms", delay),
-					}
-					fromObs <- msg.VPotLedMessage{
-						FaderNumber: byte(num),
-						LedState:    0x00,
+					l.sendAssign(byte(num), *channel)
+					if l.Flip {
+						l.sendFader(byte(num), *channel)
 					}
 				}
 			}
@@ -272,15 +363,156 @@ func (l *ChannelList) SetVolume(name string, volume float64) {
 			channel.Volume = volume
 			number := l.GetVisibleNumber(name)
 			if number != -1 {
-				fromObs <- msg.FaderMessage{
-					FaderNumber: byte(number),
-					FaderValue:  volume,
+				if l.Flip {
+					l.sendAssign(byte(number), *channel)
+				} else {
+					l.sendFader(byte(number), *channel)
+				}
+				if l.ShowValues {
+					fromObs <- msg.ChannelTextMessage{
+						FaderNumber: byte(number),
+						Text:        volumeText(volume),
+					}
 				}
 			}
 		}
 	}
 }
 
+// toggle flip mode, the faders control the assigned parameter
+// and the vpots control the volume
+func (l *ChannelList) ToggleFlip() {
+	l.Flip = !l.Flip
+	l.sync()
+}
+
+// set the assigned parameter of a channel from a flipped fader position
+func (l *ChannelList) SetFlippedFader(name string, position float64) {
+	// the top of the fader can be slightly above 1
+	position = math.Max(math.Min(position, 1), 0)
+	switch l.AssignMode {
+	case ModePan:
+		_, err := client.Inputs.SetInputAudioBalance(&inputs.SetInputAudioBalanceParams{InputName: &name, InputAudioBalance: &position})
+		if err != nil {
+			log.Print(err)
+		}
+	case ModeDelay:
+		delay := math.Round(flipDelayMin + position*(flipDelayMax-flipDelayMin))
+		_, err := client.Inputs.SetInputAudioSyncOffset(&inputs.SetInputAudioSyncOffsetParams{InputName: &name, InputAudioSyncOffset: &delay})
+		if err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+// change the volume of a channel in dB,
+// below -60dB the channel is set to -inf
+func (l *ChannelList) ChangeVolumeDb(name string, delta float64) {
+	channel, ok := l.inputs[name]
+	if !ok {
+		return
+	}
+	db := -60.0
+	if channel.Volume > 0 {
+		db = math.Max(20*math.Log10(channel.Volume), db)
+	}
+	db = math.Min(db+delta, 26)
+	volume := 0.0
+	if db > -60 {
+		volume = math.Pow(10, db/20)
+	}
+	_, err := client.Inputs.SetInputVolume(&inputs.SetInputVolumeParams{InputName: &name, InputVolumeMul: &volume})
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// send the fader of a channel, the volume or the assigned parameter when flipped
+func (l *ChannelList) sendFader(num byte, channel Channel) {
+	if !l.Flip {
+		fromObs <- msg.FaderMessage{
+			FaderNumber: num,
+			FaderValue:  channel.Volume,
+		}
+		return
+	}
+	position := 0.0
+	switch l.AssignMode {
+	case ModePan:
+		position = channel.Pan
+	case ModeDelay:
+		position = math.Max(math.Min((channel.DelayMS-flipDelayMin)/(flipDelayMax-flipDelayMin), 1), 0)
+	}
+	fromObs <- msg.FaderPositionMessage{
+		FaderNumber: num,
+		Position:    position,
+	}
+}
+
+// send the lower lcd row and the vpot led of a channel for the assign mode,
+// when flipped the vpot led shows the volume
+func (l *ChannelList) sendAssign(num byte, channel Channel) {
+	var text string
+	var led byte
+	switch l.AssignMode {
+	case ModeDelay:
+		text = fmt.Sprintf("%.0fms", channel.DelayMS)
+		led = 0x00
+	case ModePan:
+		text = fmt.Sprintf("%.2f", channel.Pan-0.5)
+		led = byte(channel.Pan*11.0 + 1)
+	case ModeTransition:
+		text, led = transitionAssign(num)
+	}
+	if l.Flip {
+		led = 0x00
+		if channel.Volume > 0 {
+			db := math.Max(20*math.Log10(channel.Volume), -60)
+			led = byte((db+60)/60*10 + 1)
+		}
+	}
+	fromObs <- msg.ChannelTextMessage{
+		FaderNumber: num,
+		Lower:       true,
+		Text:        text,
+	}
+	fromObs <- msg.VPotLedMessage{
+		FaderNumber: num,
+		LedState:    led,
+	}
+}
+
+// toggle the upper lcd row between channel names and volumes
+func (l *ChannelList) ToggleShowValues() {
+	l.ShowValues = !l.ShowValues
+	l.sync()
+}
+
+// get the text for the upper lcd row of a channel
+func (l *ChannelList) getUpperText(channel Channel) string {
+	if l.ShowValues {
+		return volumeText(channel.Volume)
+	}
+	// pinned channels are marked with a star
+	if l.rules.IsPinned(channel.Name) {
+		return "*" + channel.Name
+	}
+	return channel.Name
+}
+
+// format a volume as dB for the lcd
+func volumeText(volume float64) string {
+	db := 20 * math.Log10(volume)
+	if db <= -100 {
+		return "-inf"
+	}
+	// avoid showing "-0.0"
+	if math.Abs(db) < 0.05 {
+		db = 0
+	}
+	return fmt.Sprintf("%.1f", db)
+}
+
 // set the enabled state of a track of the selected channel
 func (l *ChannelList) SetTrack(idx byte, state bool) *Channel {
 	if channel, ok := l.inputs[l.SelectedChannel]; ok {
@@ -320,11 +552,18 @@ func (l *ChannelList) SetTracks(name string, tracksEnabled map[string]bool) {
 	}
 }
 
-// set the assign mode of the mcu (delay or pan on the vpots)
+// set the assign mode of the mcu (delay, pan or transition duration on the vpots,
+// or the scene list, the filters or the eq of the selected channel)
 func (l *ChannelList) SetAssignMode(mode byte) {
-	if mode == ModeDelay || mode == ModePan {
+	if mode <= ModeTransition {
 		if l.AssignMode != mode {
 			l.AssignMode = mode
+			switch mode {
+			case ModePlugin:
+				filterList.Update()
+			case ModeEQ:
+				equalizer.Update()
+			}
 			fromObs <- msg.AssignMessage{
 				Mode: mode,
 			}
@@ -336,6 +575,7 @@ func (l *ChannelList) SetAssignMode(mode byte) {
 // clear the channel list
 func (l *ChannelList) Clear() {
 	l.inputs = make(map[string]*Channel)
+	l.special = make(map[string]bool)
 	l.sync()
 }
 
@@ -354,17 +594,20 @@ func (l *ChannelList) sync() {
 		l.syncRetry = nil
 	}
 	// TODO: spaghetti (sync)
-	l.syncRetry = time.AfterFunc(time.Duration(config.Config.Advanced.SyncDelay)*time.Millisecond, func() { synch <- l.SyncMcu })
+	l.syncRetry = time.AfterFunc(time.Duration(config.Get().Advanced.SyncDelay)*time.Millisecond, func() { synch <- l.SyncMcu })
 }
 
 // actual sync with mcu, called from main runloop (sync channel)
 func (l *ChannelList) SyncMcu() {
 	var maxidx int = 0
 	for i, input := range l.GetVisible() {
-		fromObs <- msg.FaderMessage{
-			FaderNumber: byte(i),
-			FaderValue:  input.Volume,
+		maxidx = i + 1
+		// strips between pinned strips can be empty
+		if input.Name == "" {
+			l.clearStrip(byte(i))
+			continue
 		}
+		l.sendFader(byte(i), input)
 		fromObs <- msg.MuteMessage{
 			FaderNumber: byte(i),
 			Value:       input.Muted,
@@ -375,80 +618,61 @@ func (l *ChannelList) SyncMcu() {
 		}
 		fromObs <- msg.ChannelTextMessage{
 			FaderNumber: byte(i),
-			Text:        input.Name,
-		}
-		switch l.AssignMode {
-		case ModeDelay:
-			fromObs <- msg.ChannelTextMessage{
-				FaderNumber: byte(i),
-				Lower:       true,
-				Text:        fmt.Sprintf("%.0fms", input.DelayMS),
-			}
-			fromObs <- msg.VPotLedMessage{
-				FaderNumber: byte(i),
-				LedState:    0x00,
-			}
-		case ModePan:
-			fromObs <- msg.ChannelTextMessage{
-				FaderNumber: byte(i),
-				Lower:       true,
-				Text:        fmt.Sprintf("%.2f", input.Pan-0.5),
-			}
-			fromObs <- msg.VPotLedMessage{
-				FaderNumber: byte(i),
-				LedState:    byte(input.Pan*11.0 + 1),
-			}
+			Text:        l.getUpperText(input),
 		}
-		maxidx = i + 1
+		l.sendAssign(byte(i), input)
 	}
-	for i := maxidx; i < 8; i++ {
-		fromObs <- msg.FaderMessage{
-			FaderNumber: byte(i),
-			FaderValue:  0,
-		}
-		fromObs <- msg.MuteMessage{
-			FaderNumber: byte(i),
-			Value:       false,
-		}
-		fromObs <- msg.MonitorTypeMessage{
-			FaderNumber: byte(i),
-			MonitorType: "OBS_MONITORING_TYPE_NONE",
-		}
-		fromObs <- msg.ChannelTextMessage{
-			FaderNumber: byte(i),
-			Text:        "",
-		}
-		fromObs <- msg.ChannelTextMessage{
-			FaderNumber: byte(i),
-			Lower:       true,
-			Text:        "",
-		}
-		fromObs <- msg.VPotLedMessage{
-			FaderNumber: byte(i),
-			LedState:    0x00,
-		}
-		fromObs <- msg.MeterMessage{
-			FaderNumber: byte(i),
-			Value:       -144,
-		}
+	for i := maxidx; i < stripCount(); i++ {
+		l.clearStrip(byte(i))
 	}
 	// assign display
-	asgn := []rune{'0' + rune((l.FirstChannel+1)/10%10), '0' + rune((l.FirstChannel+1)%10)}
-	fromObs <- msg.AssignLEDMessage{
-		Characters: asgn,
+	if l.ShowsChannels() {
+		asgn := []rune{'0' + rune((l.FirstChannel+1)/10%10), '0' + rune((l.FirstChannel+1)%10)}
+		fromObs <- msg.AssignLEDMessage{
+			Characters: asgn,
+		}
+		if l.AssignMode == ModeTransition {
+			sendTransition(l.Flip)
+		}
+	} else if l.AssignMode == ModeScene {
+		sceneList.SyncMcu()
+	} else if l.AssignMode == ModePlugin {
+		filterList.SyncMcu()
+	} else if l.AssignMode == ModeEQ {
+		equalizer.SyncMcu()
 	}
 	// assign buttons
 	fromObs <- msg.AssignMessage{
 		Mode: l.AssignMode,
 	}
-	// select button
+	// name/value button
+	nameValue := msg.LedOff
+	if l.ShowValues {
+		nameValue = msg.LedOn
+	}
+	fromObs <- msg.LedMessage{
+		LedName:  "name_value",
+		LedState: nameValue,
+		Builtin:  true,
+	}
+	// flip button
+	flip := msg.LedOff
+	if l.Flip {
+		flip = msg.LedOn
+	}
+	fromObs <- msg.LedMessage{
+		LedName:  "flip",
+		LedState: flip,
+		Builtin:  true,
+	}
+	// select button, the scene list uses the select leds for the tally
 	selectNo := l.GetVisibleNumber(l.SelectedChannel)
 	if selectNo != -1 {
 		fromObs <- msg.SelectMessage{
 			FaderNumber: byte(selectNo),
 			Value:       true,
 		}
-	} else {
+	} else if l.AssignMode != ModeScene {
 		fromObs <- msg.SelectMessage{
 			FaderNumber: 0,
 			Value:       false,
@@ -478,39 +702,40 @@ func (l *ChannelList) SyncMcu() {
 	}
 	//TODO: spaghetti
 	states.SendAll()
+	master.SendPosition()
 }
 
-// get all visible channels from obs and set their state locally
-// TODO: other way to get active ones initially?
-func (l *ChannelList) UpdateVisible() {
-	resp, err := client.Scenes.GetCurrentProgramScene()
-	if err == nil {
-		list, err := client.SceneItems.GetSceneItemList(&sceneitems.GetSceneItemListParams{SceneName: &resp.CurrentProgramSceneName})
-		if err == nil {
-			for _, item := range list.SceneItems {
-				if item.SceneItemEnabled {
-					l.SetVisible(item.SourceName, true)
-				}
-				if item.SourceType == "OBS_SOURCE_TYPE_SCENE" {
-					sublist, err := client.SceneItems.GetGroupSceneItemList(&sceneitems.GetGroupSceneItemListParams{SceneName: &item.SourceName})
-					if err == nil {
-						for _, subItem := range sublist.SceneItems {
-							if subItem.SceneItemEnabled {
-								l.SetVisible(subItem.SourceName, true)
-							}
-						}
-					} else {
-						log.Print(err)
-					}
-				}
-			}
-		} else {
-			log.Print(err)
-		}
-	} else {
-		log.Print(err)
+// clear a strip without a channel
+func (l *ChannelList) clearStrip(i byte) {
+	fromObs <- msg.FaderMessage{
+		FaderNumber: i,
+		FaderValue:  0,
+	}
+	fromObs <- msg.MuteMessage{
+		FaderNumber: i,
+		Value:       false,
+	}
+	fromObs <- msg.MonitorTypeMessage{
+		FaderNumber: i,
+		MonitorType: "OBS_MONITORING_TYPE_NONE",
+	}
+	fromObs <- msg.ChannelTextMessage{
+		FaderNumber: i,
+		Text:        "",
+	}
+	fromObs <- msg.ChannelTextMessage{
+		FaderNumber: i,
+		Lower:       true,
+		Text:        "",
+	}
+	fromObs <- msg.VPotLedMessage{
+		FaderNumber: i,
+		LedState:    0x00,
+	}
+	fromObs <- msg.MeterMessage{
+		FaderNumber: i,
+		Value:       -144,
 	}
-	l.sync()
 }
 
 // adds an input and gets the basic info (mute state, volume etc)
@@ -548,12 +773,22 @@ func (l *ChannelList) UpdateSpecialInputs() error {
 
 // adds a special input and immediately sets it visible (always visible)
 func (l *ChannelList) addSpecialInput(inputName string) {
+	if inputName == "" {
+		return
+	}
+	l.special[inputName] = true
 	l.AddInput(inputName)
 	l.SetVisible(inputName, true)
 }
 
 // get the basic info of an input (volume, mute etc)
 func (l *ChannelList) getBaseInfos(inputName string) {
+	settings, err := client.Inputs.GetInputSettings(&inputs.GetInputSettingsParams{InputName: &inputName})
+	if err == nil {
+		l.inputs[inputName].Kind = settings.InputKind
+	} else {
+		log.Print(err)
+	}
 	volume, err := client.Inputs.GetInputVolume(&inputs.GetInputVolumeParams{InputName: &inputName})
 	if err == nil {
 		l.SetVolume(inputName, volume.InputVolumeMul)
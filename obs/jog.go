@@ -0,0 +1,86 @@
+package obs
+
+import (
+	"log"
+
+	"github.com/andreykaipov/goobs/api/requests/mediainputs"
+	"github.com/andreykaipov/goobs/api/requests/scenes"
+	"github.com/normen/obs-mcu/config"
+)
+
+const (
+	JogScenes = "scenes"
+	JogMedia  = "media"
+	JogVolume = "volume"
+)
+
+// toggles the scrub mode of the jog wheel
+func toggleScrub() {
+	states.SetState("ScrubMode", !states.GetState("ScrubMode"))
+}
+
+// handles a jog wheel change based on the configured mode,
+// scrub mode always scrubs the selected media source
+func processJog(amount int) {
+	if states.GetState("ScrubMode") {
+		scrubMedia(amount)
+		return
+	}
+	switch config.Get().McuJog.Mode {
+	case JogScenes:
+		stepScene(amount)
+	case JogMedia:
+		scrubMedia(amount)
+	case JogVolume:
+		nudgeVolume(amount)
+	}
+}
+
+// steps through the scene list like it is shown in obs
+func stepScene(amount int) {
+	list, err := client.Scenes.GetSceneList()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	count := len(list.Scenes)
+	if count == 0 {
+		return
+	}
+	current := 0
+	for i, scene := range list.Scenes {
+		if scene.SceneName == list.CurrentProgramSceneName {
+			current = i
+		}
+	}
+	// obs lists the scenes bottom to top
+	next := current - amount
+	next = max(next, 0)
+	next = min(next, count-1)
+	if next == current {
+		return
+	}
+	name := list.Scenes[next].SceneName
+	_, err = client.Scenes.SetCurrentProgramScene(&scenes.SetCurrentProgramSceneParams{SceneName: &name})
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// moves the playback position of the selected media source
+func scrubMedia(amount int) {
+	name := channels.SelectedChannel
+	if name == "" {
+		return
+	}
+	offset := float64(amount * config.Get().McuJog.MediaStep)
+	_, err := client.MediaInputs.OffsetMediaInputCursor(&mediainputs.OffsetMediaInputCursorParams{InputName: &name, MediaCursorOffset: &offset})
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// changes the volume of the selected channel in dB steps
+func nudgeVolume(amount int) {
+	channels.ChangeVolumeDb(channels.SelectedChannel, float64(amount)*config.Get().McuJog.VolumeStep)
+}
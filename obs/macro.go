@@ -0,0 +1,140 @@
+package obs
+
+import (
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/andreykaipov/goobs/api/requests/general"
+	"github.com/andreykaipov/goobs/api/requests/inputs"
+	"github.com/normen/obs-mcu/msg"
+)
+
+// interval of the volume changes during a fade
+const fadeInterval = 50 * time.Millisecond
+
+// the running macros by name, closing the channel cancels the macro,
+// only used by the runloop
+var macros = make(map[string]chan struct{})
+
+// starts a macro or cancels it if it is already running,
+// the steps run in their own goroutine and call the runloop for each change
+func runMacro(name string, steps []msg.MacroStep) {
+	if cancel, ok := macros[name]; ok {
+		close(cancel)
+		delete(macros, name)
+		log.Printf("Cancelled macro %s", name)
+		return
+	}
+	cancel := make(chan struct{})
+	macros[name] = cancel
+	go func() {
+		for _, step := range steps {
+			select {
+			case <-cancel:
+				return
+			default:
+			}
+			switch step.Action {
+			case "WAIT":
+				select {
+				case <-time.After(step.Duration):
+				case <-cancel:
+					return
+				}
+			case "FADE":
+				if !fadeVolume(step, cancel) {
+					return
+				}
+			default:
+				synch <- func() { runMacroStep(step) }
+			}
+		}
+		synch <- func() {
+			if macros[name] == cancel {
+				delete(macros, name)
+			}
+		}
+	}()
+}
+
+// runs a single step of a macro, called by the runloop
+func runMacroStep(step msg.MacroStep) {
+	if !connected {
+		return
+	}
+	var err error
+	switch step.Action {
+	case "KEY":
+		for _, key := range strings.Split(step.Value, ",") {
+			if _, err := client.General.TriggerHotkeyByName(&general.TriggerHotkeyByNameParams{HotkeyName: &key}); err != nil {
+				log.Print(err)
+			}
+		}
+	case "SCENE":
+		switchScene(step.Value)
+	case "REQUEST":
+		sendRequest(step.Value, step.Data)
+	case "MUTE", "UNMUTE":
+		muted := step.Action == "MUTE"
+		_, err = client.Inputs.SetInputMute(&inputs.SetInputMuteParams{InputName: &step.Value, InputMuted: &muted})
+	}
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// fades the volume of an input to the volume of the step,
+// returns false if the macro was cancelled
+func fadeVolume(step msg.MacroStep, cancel chan struct{}) bool {
+	start := make(chan float64, 1)
+	synch <- func() { start <- getVolumeDb(step.Value) }
+	startDb := <-start
+	targetDb := math.Max(step.Db, -100)
+	begin := time.Now()
+	ticker := time.NewTicker(fadeInterval)
+	defer ticker.Stop()
+	for {
+		progress := 1.0
+		if step.Duration > 0 {
+			progress = math.Min(float64(time.Since(begin))/float64(step.Duration), 1)
+		}
+		db := startDb + (targetDb-startDb)*progress
+		synch <- func() { setVolumeDb(step.Value, db) }
+		if progress >= 1 {
+			return true
+		}
+		select {
+		case <-ticker.C:
+		case <-cancel:
+			return false
+		}
+	}
+}
+
+// get the volume of an input in dB, -100 for silence
+func getVolumeDb(name string) float64 {
+	if channel, ok := channels.inputs[name]; ok && channel.Volume > 0 {
+		return math.Max(20*math.Log10(channel.Volume), -100)
+	}
+	return -100
+}
+
+// set the volume of an input in dB, -100 and below is silence
+func setVolumeDb(name string, db float64) {
+	if !connected {
+		return
+	}
+	volume := 0.0
+	if db > -100 {
+		volume = math.Pow(10, db/20)
+	}
+	_, err := client.Inputs.SetInputVolume(&inputs.SetInputVolumeParams{InputName: &name, InputVolumeMul: &volume})
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	// set locally so following steps start from here
+	channels.SetVolume(name, volume)
+}
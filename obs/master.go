@@ -0,0 +1,206 @@
+package obs
+
+import (
+	"log"
+	"math"
+
+	"github.com/andreykaipov/goobs/api/requests/filters"
+	"github.com/andreykaipov/goobs/api/requests/inputs"
+	"github.com/andreykaipov/goobs/api/requests/transitions"
+	"github.com/normen/obs-mcu/config"
+	"github.com/normen/obs-mcu/msg"
+)
+
+const (
+	MasterTBar = "tbar"
+	MasterFade = "fade"
+	MasterTrim = "trim"
+)
+
+// name and kind of the filter used to fade the program scene
+const (
+	fadeFilterName = "MCU Fade"
+	fadeFilterKind = "color_filter_v2"
+)
+
+// The master fader, depending on the config it moves the studio mode t-bar,
+// fades the program scene to black or trims all visible channels.
+type MasterFader struct {
+	Mode         string
+	Position     float64
+	ProgramScene string
+	fadeScenes   map[string]bool
+}
+
+// create a new master fader
+func NewMasterFader() *MasterFader {
+	m := &MasterFader{}
+	m.Reset()
+	return m
+}
+
+// reset the master fader to the rest position of the configured mode
+func (m *MasterFader) Reset() {
+	m.Mode = config.Get().McuFaders.FaderMaster
+	switch m.Mode {
+	case MasterFade, MasterTrim:
+		m.Position = 1
+	default:
+		m.Position = 0
+	}
+	m.fadeScenes = make(map[string]bool)
+}
+
+// set the position of the master fader (from the mcu)
+func (m *MasterFader) SetPosition(position float64) {
+	switch config.Get().McuFaders.FaderMaster {
+	case MasterTBar:
+		m.Position = position
+		// the t-bar is held while the fader moves so the transition
+		// isn't ended partway, it is released at the ends of the fader
+		m.setTBar(position <= 0 || position >= 1)
+	case MasterFade:
+		m.Position = position
+		m.applyFade(m.ProgramScene)
+	case MasterTrim:
+		m.applyTrim(position)
+	}
+}
+
+// notify the master fader that it was touched or let go,
+// letting go of the fader releases the t-bar
+func (m *MasterFader) SetTouched(pressed bool) {
+	if config.Get().McuFaders.FaderMaster == MasterTBar && !pressed {
+		m.setTBar(true)
+	}
+}
+
+// move the studio mode t-bar to the fader position
+func (m *MasterFader) setTBar(release bool) {
+	if !states.GetState("StudioMode") {
+		return
+	}
+	position := math.Max(math.Min(m.Position, 1), 0)
+	_, err := client.Transitions.SetTBarPosition(&transitions.SetTBarPositionParams{Position: &position, Release: &release})
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// set the program scene, the current fade is applied to the new scene
+func (m *MasterFader) SetProgramScene(name string) {
+	m.ProgramScene = name
+	if config.Get().McuFaders.FaderMaster == MasterFade {
+		m.applyFade(name)
+	}
+}
+
+// reads the fade state of the program scene from obs
+func (m *MasterFader) UpdateFade() {
+	if config.Get().McuFaders.FaderMaster != MasterFade || m.ProgramScene == "" {
+		return
+	}
+	name := fadeFilterName
+	filter, err := client.Filters.GetSourceFilter(&filters.GetSourceFilterParams{SourceName: &m.ProgramScene, FilterName: &name})
+	if err == nil {
+		m.fadeScenes[m.ProgramScene] = true
+		m.SetFadeSettings(m.ProgramScene, fadeFilterName, filter.FilterSettings)
+	}
+}
+
+// set the fade state from the filter settings of a source,
+// ignored if its not the fade filter of the program scene
+func (m *MasterFader) SetFadeSettings(sourceName string, filterName string, settings map[string]any) {
+	if config.Get().McuFaders.FaderMaster != MasterFade {
+		return
+	}
+	if sourceName != m.ProgramScene || filterName != fadeFilterName {
+		return
+	}
+	if opacity, ok := settings["opacity"].(float64); ok {
+		m.Position = opacity
+		m.SendPosition()
+	}
+}
+
+// notify the master fader that a filter was removed,
+// the fade filter will be created again when needed
+func (m *MasterFader) FilterRemoved(sourceName string, filterName string) {
+	if filterName == fadeFilterName {
+		delete(m.fadeScenes, sourceName)
+	}
+}
+
+// notify the master fader that a transition ended,
+// obs resets the t-bar after a transition
+func (m *MasterFader) TransitionEnded() {
+	if config.Get().McuFaders.FaderMaster == MasterTBar {
+		m.Position = 0
+		m.SendPosition()
+	}
+}
+
+// send the current position to the mcu
+func (m *MasterFader) SendPosition() {
+	fromObs <- msg.MasterFaderMessage{
+		Position: m.Position,
+	}
+}
+
+// fade a scene to black using a color filter, creates the filter if needed
+func (m *MasterFader) applyFade(scene string) {
+	if scene == "" {
+		return
+	}
+	name := fadeFilterName
+	settings := map[string]any{"opacity": m.Position}
+	if !m.fadeScenes[scene] {
+		// no need to add a filter to scenes that are not faded
+		if m.Position >= 1 {
+			return
+		}
+		_, err := client.Filters.GetSourceFilter(&filters.GetSourceFilterParams{SourceName: &scene, FilterName: &name})
+		if err != nil {
+			kind := fadeFilterKind
+			_, err = client.Filters.CreateSourceFilter(&filters.CreateSourceFilterParams{SourceName: &scene, FilterName: &name, FilterKind: &kind, FilterSettings: settings})
+			if err != nil {
+				log.Print(err)
+				return
+			}
+		}
+		m.fadeScenes[scene] = true
+	}
+	_, err := client.Filters.SetSourceFilterSettings(&filters.SetSourceFilterSettingsParams{SourceName: &scene, FilterName: &name, FilterSettings: settings})
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// change the volume of all visible channels by the
+// difference to the last trim position
+func (m *MasterFader) applyTrim(position float64) {
+	delta := trimDb(position) - trimDb(m.Position)
+	m.Position = position
+	if delta == 0 {
+		return
+	}
+	for _, channel := range channels.GetVisible() {
+		if channel.Volume <= 0 {
+			continue
+		}
+		name := channel.Name
+		db := math.Min(20*math.Log10(channel.Volume)+delta, 26)
+		_, err := client.Inputs.SetInputVolume(&inputs.SetInputVolumeParams{InputName: &name, InputVolumeDb: &db})
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+		// set locally so fast fader moves add up
+		channels.SetVolume(name, math.Pow(10, db/20))
+	}
+}
+
+// trim in dB for a fader position, top is unity
+func trimDb(position float64) float64 {
+	return math.Max(40*math.Log10(position), -60)
+}
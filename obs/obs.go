@@ -18,7 +18,9 @@ import (
 	"github.com/andreykaipov/goobs/api/events/subscriptions"
 	"github.com/andreykaipov/goobs/api/requests/general"
 	"github.com/andreykaipov/goobs/api/requests/inputs"
+	"github.com/andreykaipov/goobs/api/requests/record"
 	"github.com/andreykaipov/goobs/api/requests/scenes"
+	"github.com/andreykaipov/goobs/api/requests/stream"
 	"github.com/andreykaipov/goobs/api/typedefs"
 
 	"github.com/normen/obs-mcu/config"
@@ -34,10 +36,15 @@ var interrupt chan os.Signal
 var connection chan int
 var synch chan func()
 var connected bool
+var connectedWith connectionSettings
 
 var connectRetry *time.Timer
 var channels *ChannelList
 var states *ObsStates
+var sceneList *SceneList
+var filterList *FilterList
+var equalizer *Equalizer
+var master *MasterFader
 var fromMcu chan interface{}
 var fromObs chan interface{}
 var clientInputChannel chan interface{}
@@ -55,6 +62,10 @@ func InitObs(in chan interface{}, out chan interface{}, wg *sync.WaitGroup) {
 	waitGroup = wg
 	channels = NewChannelList()
 	states = NewObsStates()
+	sceneList = NewSceneList()
+	filterList = NewFilterList()
+	equalizer = NewEqualizer()
+	master = NewMasterFader()
 	// add always on state
 	states.SetState("AlwaysOn", true)
 	interrupt = make(chan os.Signal, 1)
@@ -67,21 +78,38 @@ func InitObs(in chan interface{}, out chan interface{}, wg *sync.WaitGroup) {
 	connection <- 0
 }
 
+// the config values used to connect to OBS
+type connectionSettings struct {
+	host     string
+	password string
+	meters   bool
+}
+
+// get the connection settings from the config
+func getConnectionSettings() connectionSettings {
+	return connectionSettings{
+		host:     config.Get().General.ObsHost,
+		password: config.Get().General.ObsPassword,
+		meters:   config.Get().McuFaders.ShowMeters,
+	}
+}
+
 // Tries to connect to OBS, called by the runloop
 func connect() error {
 	if client != nil {
 		client.Disconnect()
 	}
+	connectedWith = getConnectionSettings()
 	var err error = nil
 	// TODO: this basically blocks - the mackie channel could overflow
-	if config.Config.ShowMeters {
-		client, err = goobs.New(config.Config.General.ObsHost,
-			goobs.WithPassword(config.Config.General.ObsPassword),
+	if config.Get().ShowMeters {
+		client, err = goobs.New(config.Get().General.ObsHost,
+			goobs.WithPassword(config.Get().General.ObsPassword),
 			goobs.WithEventSubscriptions(subscriptions.All|subscriptions.InputVolumeMeters|subscriptions.InputActiveStateChanged))
 
 	} else {
-		client, err = goobs.New(config.Config.General.ObsHost,
-			goobs.WithPassword(config.Config.General.ObsPassword),
+		client, err = goobs.New(config.Get().General.ObsHost,
+			goobs.WithPassword(config.Get().General.ObsPassword),
 			goobs.WithEventSubscriptions(subscriptions.All|subscriptions.InputActiveStateChanged))
 	}
 	if err != nil {
@@ -109,7 +137,13 @@ func connect() error {
 		fromObs <- msg.DisplayTextMessage{
 			Text: scene.CurrentProgramSceneName,
 		}
+		master.Reset()
+		master.ProgramScene = scene.CurrentProgramSceneName
+		master.UpdateFade()
 	}
+	updateStates()
+	sceneList.Update()
+	updateTransition()
 	if ShowHotkeyNames {
 		hotkeys, err := client.General.GetHotkeyList(&general.GetHotkeyListParams{})
 		if err == nil {
@@ -122,6 +156,32 @@ func connect() error {
 	return nil
 }
 
+// updates the states of stream and recording and sends
+// their running time for the timecode display
+func updateOutputs() {
+	streamStatus, err := client.Stream.GetStreamStatus(&stream.GetStreamStatusParams{})
+	if err == nil {
+		states.SetState("StreamState", streamStatus.OutputActive)
+		states.SetState("StreamReconnecting", streamStatus.OutputReconnecting)
+		fromObs <- msg.OutputTimeMessage{
+			Output:   "stream",
+			Active:   streamStatus.OutputActive,
+			Duration: time.Duration(streamStatus.OutputDuration) * time.Millisecond,
+		}
+	}
+	recordStatus, err := client.Record.GetRecordStatus(&record.GetRecordStatusParams{})
+	if err == nil {
+		states.SetState("RecordState", recordStatus.OutputActive)
+		states.SetState("RecordPaused", recordStatus.OutputPaused)
+		fromObs <- msg.OutputTimeMessage{
+			Output:   "record",
+			Active:   recordStatus.OutputActive,
+			Paused:   recordStatus.OutputPaused,
+			Duration: time.Duration(recordStatus.OutputDuration) * time.Millisecond,
+		}
+	}
+}
+
 // Tries to reconnect to OBS, called by the runloop
 func retryConnect() {
 	log.Print("Retry OBS connection..")
@@ -135,6 +195,9 @@ func retryConnect() {
 func showInputs() {
 	inputs := channels.GetVisible()
 	for i, input := range inputs {
+		if input.Name == "" {
+			continue
+		}
 		log.Printf("Audio %d: %s", i, input.Name)
 	}
 }
@@ -148,16 +211,54 @@ func disconnect() {
 	}
 }
 
+// applies a reloaded config, the MCU resyncs after applying it,
+// changed connection settings (or meters) need a new connection to OBS
+func reloadConfig() {
+	states.LoadConfig()
+	channels.LoadConfig()
+	fromObs <- msg.ConfigReloadMessage{}
+	if connected {
+		updateStates()
+	}
+	if getConnectionSettings() != connectedWith {
+		log.Print("OBS connection settings changed, reconnecting..")
+		disconnect()
+		handle(connect())
+	} else if connected && master.Mode != config.Get().McuFaders.FaderMaster {
+		master.Reset()
+		master.UpdateFade()
+	}
+}
+
 // Processes a message from the MCU,
 // called by the runloop when a message is received
 func processMcuMessage(message interface{}) {
+	if _, ok := message.(msg.ConfigReloadMessage); ok {
+		reloadConfig()
+		return
+	}
 	if !connected {
 		return
 	}
+	if channels.AssignMode == ModeScene && sceneList.processMcuMessage(message) {
+		return
+	}
+	if channels.AssignMode == ModePlugin && filterList.processMcuMessage(message) {
+		return
+	}
+	if channels.AssignMode == ModeEQ && equalizer.processMcuMessage(message) {
+		return
+	}
 	switch e := message.(type) {
+	case msg.MasterFaderMessage:
+		master.SetPosition(e.Position)
+	case msg.MasterTouchMessage:
+		master.SetTouched(e.Pressed)
 	case msg.FaderMessage:
 		name := channels.GetVisibleName(e.FaderNumber)
-		if name != "" {
+		if name != "" && channels.Flip {
+			channels.SetFlippedFader(name, e.Position)
+		} else if name != "" {
 			var err error
 			_, err = client.Inputs.SetInputVolume(&inputs.SetInputVolumeParams{
 				InputName:      &name,
@@ -201,17 +302,38 @@ func processMcuMessage(message interface{}) {
 				log.Print(err)
 			}
 		}
+	case msg.MacroMessage:
+		runMacro(e.Name, e.Steps)
+	case msg.RequestMessage:
+		sendRequest(e.RequestType, e.RequestData)
+	case msg.SceneMessage:
+		switchScene(e.SceneName)
+	case msg.StudioModeMessage:
+		switch e.Action {
+		case msg.StudioToggle:
+			toggleStudioMode()
+		case msg.StudioTransition:
+			triggerTransition()
+		}
+	case msg.TransitionMessage:
+		selectTransition(e.TransitionName)
 	case msg.KeyMessage:
 		_, err := client.General.TriggerHotkeyByName(&general.TriggerHotkeyByNameParams{HotkeyName: &e.HotkeyName})
 		if err != nil {
 			log.Print(err)
 		}
 	case msg.BankMessage:
-		channels.ChangeFaderBank(e.ChangeAmount)
+		channels.ChangeFaderBank(bankAmount(e, channels.pageSize()))
 	case msg.SelectMessage:
 		channels.SetSelected(e.FaderNumber, e.Value)
 	case msg.AssignMessage:
 		channels.SetAssignMode(e.Mode)
+	case msg.NameValueMessage:
+		channels.ToggleShowValues()
+	case msg.FlipMessage:
+		channels.ToggleFlip()
+	case msg.VisibilityMessage:
+		channels.NextVisibilityMode()
 	case msg.TrackEnableMessage:
 		channel := channels.SetTrack(e.TrackNumber, e.Value)
 		if channel != nil {
@@ -222,11 +344,26 @@ func processMcuMessage(message interface{}) {
 		}
 	case msg.UpdateRequest:
 		channels.SyncMcu()
+	case msg.JogMessage:
+		processJog(e.ChangeAmount)
+	case msg.ScrubMessage:
+		toggleScrub()
 	case msg.VPotButtonMessage:
 		name := channels.GetVisibleName(e.FaderNumber)
 		balhalf := 0.5
 		minval := 0.0
-		if name != "" {
+		unity := 1.0
+		if channels.AssignMode == ModeTransition && !channels.Flip {
+			// the first strip selects the next transition
+			if e.FaderNumber == 0 {
+				nextTransition()
+			}
+		} else if name != "" && channels.Flip {
+			_, err := client.Inputs.SetInputVolume(&inputs.SetInputVolumeParams{InputName: &name, InputVolumeMul: &unity})
+			if err != nil {
+				log.Print(err)
+			}
+		} else if name != "" {
 			switch channels.AssignMode {
 			case ModePan:
 				_, err := client.Inputs.SetInputAudioBalance(&inputs.SetInputAudioBalanceParams{InputName: &name, InputAudioBalance: &balhalf})
@@ -242,7 +379,14 @@ func processMcuMessage(message interface{}) {
 		}
 	case msg.VPotChangeMessage:
 		name := channels.GetVisibleName(e.FaderNumber)
-		if name != "" {
+		if channels.AssignMode == ModeTransition && !channels.Flip {
+			// the first strip changes the duration of the transition
+			if e.FaderNumber == 0 {
+				changeTransitionDuration(e.ChangeAmount)
+			}
+		} else if name != "" && channels.Flip {
+			channels.ChangeVolumeDb(name, float64(e.ChangeAmount)*0.5)
+		} else if name != "" {
 			switch channels.AssignMode {
 			case ModePan:
 				newPan := channels.GetPan(name) + float64(e.ChangeAmount)/50.0
@@ -272,9 +416,10 @@ func processObsMessage(event interface{}) {
 	switch e := event.(type) {
 	//TODO: special inputs changed
 	case *events.InputActiveStateChanged:
-		channels.SetVisible(e.InputName, e.VideoActive)
+		channels.SetActive(e.InputName, e.VideoActive)
 	case *events.InputMuteStateChanged:
 		channels.SetMuted(e.InputName, e.InputMuted)
+		states.SetState("InputMuted:"+e.InputName, e.InputMuted)
 	case *events.InputVolumeChanged:
 		channels.SetVolume(e.InputName, e.InputVolumeMul)
 	case *events.InputNameChanged:
@@ -291,6 +436,46 @@ func processObsMessage(event interface{}) {
 		fromObs <- msg.DisplayTextMessage{
 			Text: e.SceneName,
 		}
+		master.SetProgramScene(e.SceneName)
+		setProgramScene(e.SceneName)
+		channels.ScenesChanged()
+	case *events.CurrentPreviewSceneChanged:
+		setPreviewScene(e.SceneName)
+		channels.ScenesChanged()
+	case *events.SceneListChanged:
+		sceneList.SetScenes(e.Scenes)
+	case *events.SceneNameChanged:
+		sceneList.Update()
+	case *events.CurrentSceneTransitionChanged:
+		updateTransition()
+	case *events.CurrentSceneTransitionDurationChanged:
+		setTransitionDuration(e.TransitionDuration)
+	case *events.SceneTransitionEnded:
+		master.TransitionEnded()
+	case *events.SourceFilterSettingsChanged:
+		master.SetFadeSettings(e.SourceName, e.FilterName, e.FilterSettings)
+		filterList.SetSettings(e.SourceName, e.FilterName, e.FilterSettings)
+		equalizer.SetSettings(e.SourceName, e.FilterName, e.FilterSettings)
+	case *events.SourceFilterRemoved:
+		master.FilterRemoved(e.SourceName, e.FilterName)
+		filterList.FiltersChanged(e.SourceName)
+		equalizer.FiltersChanged(e.SourceName)
+	case *events.SourceFilterCreated:
+		filterList.FiltersChanged(e.SourceName)
+		equalizer.FiltersChanged(e.SourceName)
+	case *events.SourceFilterNameChanged:
+		filterList.FiltersChanged(e.SourceName)
+		equalizer.FiltersChanged(e.SourceName)
+	case *events.SourceFilterListReindexed:
+		filterList.FiltersChanged(e.SourceName)
+	case *events.SourceFilterEnableStateChanged:
+		filterList.SetEnabled(e.SourceName, e.FilterName, e.FilterEnabled)
+	case *events.StudioModeStateChanged:
+		states.SetState("StudioMode", e.StudioModeEnabled)
+		if !e.StudioModeEnabled {
+			setPreviewScene("")
+		}
+		channels.ScenesChanged()
 	case *events.InputAudioTracksChanged:
 		channels.SetTracks(e.InputName, map[string]bool(*e.InputAudioTracks))
 	case *events.InputAudioBalanceChanged:
@@ -327,8 +512,25 @@ func processObsMessage(event interface{}) {
 		}
 	case *events.StreamStateChanged:
 		states.SetState("StreamState", e.OutputActive)
+		states.SetState("StreamReconnecting", e.OutputState == "OBS_WEBSOCKET_OUTPUT_RECONNECTING")
+		updateOutputs()
 	case *events.RecordStateChanged:
 		states.SetState("RecordState", e.OutputActive)
+		states.SetState("RecordPaused", e.OutputState == "OBS_WEBSOCKET_OUTPUT_PAUSED")
+		updateOutputs()
+	case *events.ReplayBufferStateChanged:
+		states.SetState("ReplayBufferState", e.OutputActive)
+	case *events.VirtualcamStateChanged:
+		states.SetState("VirtualCamState", e.OutputActive)
+	case *events.SceneItemEnableStateChanged:
+		setSceneItemEnabled(e.SceneName, e.SceneItemId, e.SceneItemEnabled)
+		channels.SceneItemsChanged(e.SceneName)
+	case *events.SceneItemCreated:
+		updateSceneItems()
+		channels.SceneItemsChanged(e.SceneName)
+	case *events.SceneItemRemoved:
+		updateSceneItems()
+		channels.SceneItemsChanged(e.SceneName)
 	case error:
 		uw := errors.Unwrap(e)
 		switch uw.(type) {
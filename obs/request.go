@@ -0,0 +1,61 @@
+package obs
+
+import (
+	"encoding/json"
+	"log"
+	"reflect"
+	"unsafe"
+
+	"github.com/andreykaipov/goobs"
+	"github.com/andreykaipov/goobs/api"
+)
+
+// a request with any type and data
+type rawRequest struct {
+	requestType string
+	requestData json.RawMessage
+}
+
+func (r *rawRequest) GetRequestName() string {
+	return r.requestType
+}
+
+func (r *rawRequest) MarshalJSON() ([]byte, error) {
+	if len(r.requestData) == 0 {
+		return []byte("{}"), nil
+	}
+	return r.requestData, nil
+}
+
+// the response to a raw request, only the raw data is kept
+type rawResponse struct {
+	api.ResponseCommon
+}
+
+// sends any request to obs, the request data is a json object
+func sendRequest(requestType string, requestData json.RawMessage) {
+	apiClient := getApiClient(client)
+	if apiClient == nil {
+		return
+	}
+	response := &rawResponse{}
+	err := apiClient.SendRequest(&rawRequest{requestType, requestData}, response)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	log.Printf("Request %s: %s", requestType, response.GetRaw())
+}
+
+// get the internal client of goobs, goobs has no public way
+// to send a request that is only known by its name.
+// this relies on the layout of goobs v1.5.1 (goobs.Client.client *api.Client),
+// check it again when updating goobs
+func getApiClient(c *goobs.Client) *api.Client {
+	field := reflect.ValueOf(c).Elem().FieldByName("client")
+	if !field.IsValid() || field.Type() != reflect.TypeOf((*api.Client)(nil)) {
+		log.Print("Error: Can't send raw requests with this goobs version")
+		return nil
+	}
+	return *(**api.Client)(unsafe.Pointer(field.UnsafeAddr()))
+}
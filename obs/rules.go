@@ -0,0 +1,167 @@
+package obs
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/normen/obs-mcu/config"
+)
+
+// a channel name from the config, either an exact name
+// or a regular expression if it is enclosed in slashes
+type namePattern struct {
+	name   string
+	regexp *regexp.Regexp
+}
+
+// checks if a name matches the pattern
+func (p namePattern) matches(name string) bool {
+	if p.regexp != nil {
+		return p.regexp.MatchString(name)
+	}
+	return p.name == name
+}
+
+// The rules from the config that decide which channels are shown
+// and in which order, channels not in the order list are sorted alphabetically.
+type ChannelRules struct {
+	include      []namePattern
+	exclude      []namePattern
+	excludeKinds []string
+	order        []string
+	// channel names by their strip index
+	pinned map[int]string
+}
+
+// reads the channel rules from the config, invalid patterns are skipped
+func loadChannelRules() (*ChannelRules, []error) {
+	errs := []error{}
+	include, includeErrs := parsePatterns(config.Get().McuChannels.Include)
+	exclude, excludeErrs := parsePatterns(config.Get().McuChannels.Exclude)
+	for _, err := range includeErrs {
+		errs = append(errs, fmt.Errorf("[mcu_channels] include: %v", err))
+	}
+	for _, err := range excludeErrs {
+		errs = append(errs, fmt.Errorf("[mcu_channels] exclude: %v", err))
+	}
+	if !slices.Contains(visibilityModes, config.Get().McuChannels.Visibility) {
+		errs = append(errs, fmt.Errorf("[mcu_channels] visibility: unknown mode %q", config.Get().McuChannels.Visibility))
+	}
+	pinned, pinnedErrs := parsePinned(config.Get().McuChannels.Pinned)
+	for _, err := range pinnedErrs {
+		errs = append(errs, fmt.Errorf("[mcu_channels] pinned: %v", err))
+	}
+	rules := &ChannelRules{
+		include:      include,
+		exclude:      exclude,
+		excludeKinds: trimEntries(config.Get().McuChannels.ExcludeKinds),
+		order:        trimEntries(config.Get().McuChannels.Order),
+		pinned:       pinned,
+	}
+	return rules, errs
+}
+
+// parses a list of names and regular expressions
+func parsePatterns(entries []string) ([]namePattern, []error) {
+	patterns := []namePattern{}
+	errs := []error{}
+	for _, entry := range trimEntries(entries) {
+		if len(entry) > 1 && strings.HasPrefix(entry, "/") && strings.HasSuffix(entry, "/") {
+			re, err := regexp.Compile(entry[1 : len(entry)-1])
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			patterns = append(patterns, namePattern{regexp: re})
+		} else {
+			patterns = append(patterns, namePattern{name: entry})
+		}
+	}
+	return patterns, errs
+}
+
+// parses a list of pinned channels like "8:Desktop Audio",
+// the strips are counted from 1, strips beyond the strip count are left out
+func parsePinned(entries []string) (map[int]string, []error) {
+	pinned := make(map[int]string)
+	errs := []error{}
+	for _, entry := range trimEntries(entries) {
+		strip, name, found := strings.Cut(entry, ":")
+		number, err := strconv.Atoi(strings.TrimSpace(strip))
+		name = strings.TrimSpace(name)
+		if !found || err != nil || number < 1 || name == "" {
+			errs = append(errs, fmt.Errorf("invalid entry %q, use <strip>:<channel>", entry))
+			continue
+		}
+		if number > stripCount() {
+			errs = append(errs, fmt.Errorf("strip %d of %q is beyond the %d strips", number, name, stripCount()))
+			continue
+		}
+		if _, ok := pinned[number-1]; ok {
+			errs = append(errs, fmt.Errorf("strip %d is pinned twice", number))
+			continue
+		}
+		pinned[number-1] = name
+	}
+	return pinned, errs
+}
+
+// trims the entries of a list and removes empty ones
+func trimEntries(entries []string) []string {
+	trimmed := []string{}
+	for _, entry := range entries {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			trimmed = append(trimmed, entry)
+		}
+	}
+	return trimmed
+}
+
+// checks if a name matches any of the patterns
+func matchesAny(patterns []namePattern, name string) bool {
+	for _, p := range patterns {
+		if p.matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// checks if a channel can be shown on the mcu, the exclude rules win over the include list
+func (r *ChannelRules) Allows(channel *Channel) bool {
+	if len(r.include) > 0 && !matchesAny(r.include, channel.Name) {
+		return false
+	}
+	if matchesAny(r.exclude, channel.Name) {
+		return false
+	}
+	return !slices.Contains(r.excludeKinds, channel.Kind)
+}
+
+// compares two channel names for sorting, channels in the order list come first
+func (r *ChannelRules) Compare(a string, b string) int {
+	idxA := slices.Index(r.order, a)
+	idxB := slices.Index(r.order, b)
+	switch {
+	case idxA != -1 && idxB != -1:
+		return idxA - idxB
+	case idxA != -1:
+		return -1
+	case idxB != -1:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// checks if a channel is pinned to a strip
+func (r *ChannelRules) IsPinned(name string) bool {
+	for _, pinnedName := range r.pinned {
+		if pinnedName == name {
+			return true
+		}
+	}
+	return false
+}
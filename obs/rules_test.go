@@ -0,0 +1,42 @@
+package obs
+
+import "testing"
+
+func TestParsePinned(t *testing.T) {
+	// the default config has no extenders, 8 strips
+	if stripCount() != 8 {
+		t.Fatalf("default config has %d strips", stripCount())
+	}
+	tests := []struct {
+		entries []string
+		want    map[int]string
+		errs    int
+	}{
+		{[]string{"1:Mic"}, map[int]string{0: "Mic"}, 0},
+		{[]string{" 8 : Desktop Audio "}, map[int]string{7: "Desktop Audio"}, 0},
+		{[]string{"2:Mic:Left"}, map[int]string{1: "Mic:Left"}, 0},
+		{[]string{"9:Mic"}, map[int]string{}, 1},
+		{[]string{"1:Mic", "9:Music"}, map[int]string{0: "Mic"}, 1},
+		{[]string{"0:Mic"}, map[int]string{}, 1},
+		{[]string{"one:Mic"}, map[int]string{}, 1},
+		{[]string{"1:"}, map[int]string{}, 1},
+		{[]string{"Mic"}, map[int]string{}, 1},
+		{[]string{"1:Mic", "1:Music"}, map[int]string{0: "Mic"}, 1},
+		{[]string{""}, map[int]string{}, 0},
+	}
+	for _, test := range tests {
+		pinned, errs := parsePinned(test.entries)
+		if len(errs) != test.errs {
+			t.Errorf("parsePinned(%q) has %d errors, want %d: %v", test.entries, len(errs), test.errs, errs)
+		}
+		if len(pinned) != len(test.want) {
+			t.Errorf("parsePinned(%q) = %v, want %v", test.entries, pinned, test.want)
+			continue
+		}
+		for strip, name := range test.want {
+			if pinned[strip] != name {
+				t.Errorf("parsePinned(%q) = %v, want %v", test.entries, pinned, test.want)
+			}
+		}
+	}
+}
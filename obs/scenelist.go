@@ -0,0 +1,121 @@
+package obs
+
+import (
+	"log"
+	"slices"
+
+	"github.com/andreykaipov/goobs/api/requests/scenes"
+	"github.com/andreykaipov/goobs/api/typedefs"
+	"github.com/normen/obs-mcu/msg"
+)
+
+// The scenes shown on the strips in scene mode, top to bottom like in obs.
+// Select switches the program scene, Rec sets the preview scene.
+type SceneList struct {
+	scenes     []string
+	FirstScene int
+}
+
+// create a new scene list
+func NewSceneList() *SceneList {
+	return &SceneList{}
+}
+
+// gets the scene list from obs
+func (s *SceneList) Update() {
+	list, err := client.Scenes.GetSceneList()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	s.SetScenes(list.Scenes)
+}
+
+// sets the scenes from a scene list of obs
+func (s *SceneList) SetScenes(list []*typedefs.Scene) {
+	s.scenes = []string{}
+	for _, scene := range list {
+		s.scenes = append(s.scenes, scene.SceneName)
+	}
+	// obs lists the scenes bottom to top
+	slices.Reverse(s.scenes)
+	if channels.AssignMode == ModeScene {
+		channels.sync()
+	}
+}
+
+// change the first scene shown on the mcu
+func (s *SceneList) ChangeBank(amount int) {
+	s.FirstScene = max(min(s.FirstScene+amount, len(s.scenes)-1), 0)
+	channels.sync()
+}
+
+// get the name of the scene shown on a strip
+func (s *SceneList) GetVisibleName(index byte) string {
+	idx := s.FirstScene + int(index)
+	if idx < len(s.scenes) {
+		return s.scenes[idx]
+	}
+	return ""
+}
+
+// sends the tally of all strips, called when the program or preview scene changed
+func (s *SceneList) SendTally() {
+	if channels.AssignMode != ModeScene {
+		return
+	}
+	for i := 0; i < stripCount(); i++ {
+		name := s.GetVisibleName(byte(i))
+		fromObs <- msg.TallyMessage{
+			FaderNumber: byte(i),
+			Program:     name != "" && name == programScene,
+			Preview:     name != "" && name == previewScene,
+		}
+	}
+}
+
+// sends the scene names and the tally to the mcu,
+// called from ChannelList.SyncMcu after the strips were cleared
+func (s *SceneList) SyncMcu() {
+	for i := 0; i < stripCount(); i++ {
+		fromObs <- msg.ChannelTextMessage{
+			FaderNumber: byte(i),
+			Text:        s.GetVisibleName(byte(i)),
+		}
+	}
+	asgn := []rune{'0' + rune((s.FirstScene+1)/10%10), '0' + rune((s.FirstScene+1)%10)}
+	fromObs <- msg.AssignLEDMessage{
+		Characters: asgn,
+	}
+	s.SendTally()
+}
+
+// handles the strip messages of the mcu in scene mode,
+// returns true if the message was used
+func (s *SceneList) processMcuMessage(message interface{}) bool {
+	switch e := message.(type) {
+	case msg.BankMessage:
+		s.ChangeBank(bankAmount(e, stripCount()))
+	case msg.SelectMessage:
+		name := s.GetVisibleName(e.FaderNumber)
+		if name != "" {
+			_, err := client.Scenes.SetCurrentProgramScene(&scenes.SetCurrentProgramSceneParams{SceneName: &name})
+			if err != nil {
+				log.Print(err)
+			}
+		}
+	case msg.MonitorTypeMessage:
+		name := s.GetVisibleName(e.FaderNumber)
+		if name != "" && e.MonitorType == OBS_MONITORING_TYPE_MONITOR_ONLY {
+			_, err := client.Scenes.SetCurrentPreviewScene(&scenes.SetCurrentPreviewSceneParams{SceneName: &name})
+			if err != nil {
+				log.Print(err)
+			}
+		}
+	case msg.FaderMessage, msg.MuteMessage, msg.VPotChangeMessage, msg.VPotButtonMessage:
+		// the strips have no channels in scene mode
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package obs
+
+import (
+	"log"
+
+	"github.com/andreykaipov/goobs/api/requests/scenes"
+)
+
+// the current program and preview scene, used for the tally of scene buttons
+var programScene string
+var previewScene string
+
+// sets the program scene and the "SceneActive:<name>" states
+func setProgramScene(name string) {
+	states.SetState("SceneActive:"+programScene, false)
+	programScene = name
+	states.SetState("SceneActive:"+programScene, true)
+	sceneList.SendTally()
+}
+
+// sets the preview scene and the "ScenePreview:<name>" states,
+// empty when studio mode is off
+func setPreviewScene(name string) {
+	states.SetState("ScenePreview:"+previewScene, false)
+	previewScene = name
+	if previewScene != "" {
+		states.SetState("ScenePreview:"+previewScene, true)
+	}
+	sceneList.SendTally()
+}
+
+// gets the program and preview scene from obs
+func updateScenes() {
+	program, err := client.Scenes.GetCurrentProgramScene(&scenes.GetCurrentProgramSceneParams{})
+	if err == nil {
+		setProgramScene(program.CurrentProgramSceneName)
+	}
+	if states.GetState("StudioMode") {
+		preview, err := client.Scenes.GetCurrentPreviewScene(&scenes.GetCurrentPreviewSceneParams{})
+		if err == nil {
+			setPreviewScene(preview.CurrentPreviewSceneName)
+		}
+	} else {
+		setPreviewScene("")
+	}
+}
+
+// switches to a scene, in studio mode the scene is set as preview
+func switchScene(name string) {
+	var err error
+	if states.GetState("StudioMode") {
+		_, err = client.Scenes.SetCurrentPreviewScene(&scenes.SetCurrentPreviewSceneParams{SceneName: &name})
+	} else {
+		_, err = client.Scenes.SetCurrentProgramScene(&scenes.SetCurrentProgramSceneParams{SceneName: &name})
+	}
+	if err != nil {
+		log.Print(err)
+	}
+}
@@ -1,39 +1,59 @@
 package obs
 
 import (
-	"reflect"
+	"fmt"
+	"log"
+	"slices"
 	"strings"
 
 	"github.com/normen/obs-mcu/config"
 	"github.com/normen/obs-mcu/msg"
 )
 
+// one obs state that lights a led, either steady or blinking
 type ObsState struct {
 	StateName string
 	LedName   string
-	State     bool
+	Blink     bool
 }
 
 type ObsStates struct {
-	states map[string][]*ObsState
+	states    map[string][]*ObsState
+	leds      map[string][]*ObsState
+	values    map[string]bool
+	ledStates map[string]msg.LedState
 }
 
 func NewObsStates() *ObsStates {
 	ret := &ObsStates{
-		states: make(map[string][]*ObsState),
+		states:    make(map[string][]*ObsState),
+		leds:      make(map[string][]*ObsState),
+		values:    make(map[string]bool),
+		ledStates: make(map[string]msg.LedState),
 	}
 	ret.getConfig()
 	return ret
 }
 
+// LoadConfig reads the led mappings from the config again,
+// the state values are kept
+func (s *ObsStates) LoadConfig() {
+	s.states = make(map[string][]*ObsState)
+	s.leds = make(map[string][]*ObsState)
+	s.ledStates = make(map[string]msg.LedState)
+	s.getConfig()
+}
+
 func (s *ObsStates) SetState(name string, state bool) {
+	s.values[name] = state
 	if sts, ok := s.states[name]; ok {
 		for _, st := range sts {
-			if st.State != state {
-				st.State = state
+			ledState := s.getLedState(st.LedName)
+			if s.ledStates[st.LedName] != ledState {
+				s.ledStates[st.LedName] = ledState
 				fromObs <- msg.LedMessage{
 					LedName:  st.LedName,
-					LedState: st.State,
+					LedState: ledState,
 				}
 			}
 		}
@@ -41,49 +61,147 @@ func (s *ObsStates) SetState(name string, state bool) {
 }
 
 func (s *ObsStates) SendAll() {
-	for _, sts := range s.states {
-		for _, st := range sts {
-			fromObs <- msg.LedMessage{
-				LedName:  st.LedName,
-				LedState: st.State,
-			}
+	for ledName := range s.leds {
+		ledState := s.getLedState(ledName)
+		s.ledStates[ledName] = ledState
+		fromObs <- msg.LedMessage{
+			LedName:  ledName,
+			LedState: ledState,
 		}
 	}
 }
 
-//func (s *ObsStates) GetState(name string) *ObsState {
-//if st, ok := s.states[name]; ok {
-//return st
-//}
-//return nil
-//}
+// get the last value set for a state, also for states without a led
+func (s *ObsStates) GetState(name string) bool {
+	return s.values[name]
+}
+
+// get the names of all configured states with the given prefix
+func (s *ObsStates) GetStateNames(prefix string) []string {
+	names := []string{}
+	for name := range s.states {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// get the combined state of a led, blinking wins over steady
+func (s *ObsStates) getLedState(ledName string) msg.LedState {
+	ledState := msg.LedOff
+	for _, st := range s.leds[ledName] {
+		if s.values[st.StateName] {
+			if st.Blink {
+				ledState = msg.LedBlink
+			} else if ledState == msg.LedOff {
+				ledState = msg.LedOn
+			}
+		}
+	}
+	return ledState
+}
 
 //func (s *ObsStates) DeleteState(name string, state bool) {
 //delete(s.states, name)
 //}
 
+// all states that can be used in the led config
+var knownStates = []string{
+	"AlwaysOn",
+	"StreamState",
+	"StreamReconnecting",
+	"RecordState",
+	"RecordPaused",
+	"ReplayBufferState",
+	"VirtualCamState",
+	"StudioMode",
+	"ScrubMode",
+}
+
+// states that take a parameter after the prefix, e.g. "InputMuted:Mic"
+var parameterStates = []string{
+	"SceneActive:",
+	"ScenePreview:",
+	"InputMuted:",
+	"SceneItemEnabled:",
+}
+
+// reads the led config, one led can have multiple states, separated by comma
+// e.g. "STATE:RecordState,BLINK:RecordPaused"
 func (t *ObsStates) getConfig() {
-	s := reflect.ValueOf(config.Config.McuLeds).Elem()
-	num := s.NumField()
-	for i := 0; i < num; i++ {
-		fieldVal := s.FieldByIndex([]int{i})
-		if fieldVal.IsValid() && fieldVal.Kind() == reflect.String {
-			ledName := reflect.TypeOf(*config.Config.McuLeds).Field(i).Name
-			configVal := fieldVal.String()
-			if configVal != "" {
-				ledType, stateName, found := strings.Cut(configVal, ":")
-				if found {
-					switch ledType {
-					case "STATE":
-						st := ObsState{
-							StateName: stateName,
-							LedName:   ledName,
-							State:     false,
-						}
-						t.states[stateName] = append(t.states[stateName], &st)
-					}
-				}
+	for ledName, configVal := range config.Get().LedMap {
+		sts, errs := parseLedConfig(ledName, configVal)
+		for _, err := range errs {
+			log.Printf("LED %s: %v", ledName, err)
+		}
+		for _, st := range sts {
+			t.addState(st)
+		}
+	}
+	// buttons that switch scenes show the tally, unless their led is mapped
+	for buttonName, command := range config.Get().ButtonMap {
+		sceneName, found := strings.CutPrefix(command, "SCENE:")
+		if found && config.Get().LedMap[buttonName] == "" {
+			t.addState(&ObsState{StateName: "SceneActive:" + sceneName, LedName: buttonName})
+			t.addState(&ObsState{StateName: "ScenePreview:" + sceneName, LedName: buttonName, Blink: true})
+		}
+	}
+}
+
+// adds a state to the led it lights
+func (t *ObsStates) addState(st *ObsState) {
+	t.states[st.StateName] = append(t.states[st.StateName], st)
+	t.leds[st.LedName] = append(t.leds[st.LedName], st)
+}
+
+// parses the config value of one led
+func parseLedConfig(ledName string, configVal string) ([]*ObsState, []error) {
+	sts := []*ObsState{}
+	errs := []error{}
+	if configVal == "" || configVal == config.Disabled {
+		return sts, errs
+	}
+	for _, entry := range strings.Split(configVal, ",") {
+		ledType, stateName, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if !found {
+			errs = append(errs, fmt.Errorf("missing type in %q", entry))
+			continue
+		}
+		switch ledType {
+		case "STATE", "BLINK":
+			if err := checkStateName(stateName); err != nil {
+				errs = append(errs, err)
 			}
+			sts = append(sts, &ObsState{
+				StateName: stateName,
+				LedName:   ledName,
+				Blink:     ledType == "BLINK",
+			})
+		default:
+			errs = append(errs, fmt.Errorf("unknown type %q", ledType))
+		}
+	}
+	return sts, errs
+}
+
+// checks if a state name is known, parameterized states need a parameter
+func checkStateName(stateName string) error {
+	if slices.Contains(knownStates, stateName) {
+		return nil
+	}
+	for _, prefix := range parameterStates {
+		param, found := strings.CutPrefix(stateName, prefix)
+		if !found {
+			continue
+		}
+		if param == "" {
+			return fmt.Errorf("missing parameter in state %q", stateName)
+		}
+		if prefix == "SceneItemEnabled:" && !strings.Contains(param, "/") {
+			return fmt.Errorf("state %q needs scene and source separated by /", stateName)
 		}
+		return nil
 	}
+	return fmt.Errorf("unknown state %q", stateName)
 }
@@ -0,0 +1,144 @@
+package obs
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/andreykaipov/goobs/api/requests/transitions"
+	"github.com/andreykaipov/goobs/api/requests/ui"
+	"github.com/normen/obs-mcu/msg"
+)
+
+// range and vpot step of the transition duration in ms
+const (
+	transitionDurationMin  = 50.0
+	transitionDurationMax  = 20000.0
+	transitionDurationStep = 50.0
+)
+
+// duration in ms that lights the full vpot ring in transition mode
+const transitionRingMax = 5000.0
+
+// the current scene transition, shown on the lcd in transition mode
+var transitionName string
+var transitionDuration float64
+var transitionFixed bool
+
+// gets the current scene transition from obs
+func updateTransition() {
+	resp, err := client.Transitions.GetCurrentSceneTransition(&transitions.GetCurrentSceneTransitionParams{})
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	transitionName = resp.TransitionName
+	transitionDuration = resp.TransitionDuration
+	transitionFixed = resp.TransitionFixed
+	syncTransition()
+}
+
+// sets the duration of the current transition when obs changed it
+func setTransitionDuration(duration float64) {
+	transitionDuration = duration
+	syncTransition()
+}
+
+// syncs the strips when the transition mode is active
+func syncTransition() {
+	if channels.AssignMode == ModeTransition {
+		channels.sync()
+	}
+}
+
+// get the lower lcd text and the vpot led of a strip in transition mode,
+// the first strip shows the duration and the second the transition name
+func transitionAssign(strip byte) (string, byte) {
+	switch strip {
+	case 0:
+		if transitionFixed {
+			return "fixed", 0x00
+		}
+		led := byte(math.Min(transitionDuration/transitionRingMax, 1)*10 + 1)
+		return fmt.Sprintf("%.0fms", transitionDuration), led
+	case 1:
+		return transitionName, 0x00
+	}
+	return "", 0x00
+}
+
+// sends the transition to the first strips, also when they show no channel
+func sendTransition(flip bool) {
+	for i := byte(0); i < 2; i++ {
+		text, led := transitionAssign(i)
+		fromObs <- msg.ChannelTextMessage{
+			FaderNumber: i,
+			Lower:       true,
+			Text:        text,
+		}
+		// the vpots control the volume when flipped
+		if !flip {
+			fromObs <- msg.VPotLedMessage{
+				FaderNumber: i,
+				LedState:    led,
+			}
+		}
+	}
+}
+
+// changes the duration of the current transition in vpot steps
+func changeTransitionDuration(amount int) {
+	if transitionFixed {
+		return
+	}
+	duration := transitionDuration + float64(amount)*transitionDurationStep
+	duration = math.Max(math.Min(duration, transitionDurationMax), transitionDurationMin)
+	_, err := client.Transitions.SetCurrentSceneTransitionDuration(&transitions.SetCurrentSceneTransitionDurationParams{TransitionDuration: &duration})
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// sets the current transition
+func selectTransition(name string) {
+	_, err := client.Transitions.SetCurrentSceneTransition(&transitions.SetCurrentSceneTransitionParams{TransitionName: &name})
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// selects the next transition of the transition list
+func nextTransition() {
+	list, err := client.Transitions.GetSceneTransitionList()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	if len(list.Transitions) == 0 {
+		return
+	}
+	next := 0
+	for i, transition := range list.Transitions {
+		if transition.TransitionName == list.CurrentSceneTransitionName {
+			next = (i + 1) % len(list.Transitions)
+		}
+	}
+	selectTransition(list.Transitions[next].TransitionName)
+}
+
+// toggles studio mode
+func toggleStudioMode() {
+	enabled := !states.GetState("StudioMode")
+	_, err := client.Ui.SetStudioModeEnabled(&ui.SetStudioModeEnabledParams{StudioModeEnabled: &enabled})
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// transitions the preview scene to program in studio mode
+func triggerTransition() {
+	_, err := client.Transitions.TriggerStudioModeTransition()
+	if err != nil {
+		log.Print(err)
+	}
+}
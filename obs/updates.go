@@ -0,0 +1,74 @@
+package obs
+
+import (
+	"log"
+	"strings"
+
+	"github.com/andreykaipov/goobs/api/requests/inputs"
+	"github.com/andreykaipov/goobs/api/requests/outputs"
+	"github.com/andreykaipov/goobs/api/requests/sceneitems"
+	"github.com/andreykaipov/goobs/api/requests/ui"
+)
+
+// the "SceneItemEnabled:<scene>/<source>" states by scene name and item id
+var sceneItemStates = map[string]map[int]string{}
+
+// gets the current value of all states from obs,
+// called on connect and when the config changed
+func updateStates() {
+	studio, err := client.Ui.GetStudioModeEnabled(&ui.GetStudioModeEnabledParams{})
+	if err == nil {
+		states.SetState("StudioMode", studio.StudioModeEnabled)
+	}
+	replay, err := client.Outputs.GetReplayBufferStatus(&outputs.GetReplayBufferStatusParams{})
+	if err == nil {
+		states.SetState("ReplayBufferState", replay.OutputActive)
+	}
+	virtualCam, err := client.Outputs.GetVirtualCamStatus(&outputs.GetVirtualCamStatusParams{})
+	if err == nil {
+		states.SetState("VirtualCamState", virtualCam.OutputActive)
+	}
+	updateScenes()
+	updateOutputs()
+	for _, stateName := range states.GetStateNames("InputMuted:") {
+		inputName := strings.TrimPrefix(stateName, "InputMuted:")
+		mute, err := client.Inputs.GetInputMute(&inputs.GetInputMuteParams{InputName: &inputName})
+		if err != nil {
+			log.Printf("Could not get mute state of %s: %v", inputName, err)
+			continue
+		}
+		states.SetState(stateName, mute.InputMuted)
+	}
+	updateSceneItems()
+}
+
+// looks up the scene items of all "SceneItemEnabled:<scene>/<source>" states
+// and gets their enabled state
+func updateSceneItems() {
+	sceneItemStates = map[string]map[int]string{}
+	for _, stateName := range states.GetStateNames("SceneItemEnabled:") {
+		sceneName, sourceName, _ := strings.Cut(strings.TrimPrefix(stateName, "SceneItemEnabled:"), "/")
+		item, err := client.SceneItems.GetSceneItemId(&sceneitems.GetSceneItemIdParams{SceneName: &sceneName, SourceName: &sourceName})
+		if err != nil {
+			states.SetState(stateName, false)
+			continue
+		}
+		enabled, err := client.SceneItems.GetSceneItemEnabled(&sceneitems.GetSceneItemEnabledParams{SceneName: &sceneName, SceneItemId: &item.SceneItemId})
+		if err != nil {
+			log.Printf("Could not get state of %s in %s: %v", sourceName, sceneName, err)
+			continue
+		}
+		if sceneItemStates[sceneName] == nil {
+			sceneItemStates[sceneName] = map[int]string{}
+		}
+		sceneItemStates[sceneName][item.SceneItemId] = stateName
+		states.SetState(stateName, enabled.SceneItemEnabled)
+	}
+}
+
+// sets the "SceneItemEnabled:<scene>/<source>" state of a scene item, if configured
+func setSceneItemEnabled(sceneName string, itemId int, enabled bool) {
+	if stateName, ok := sceneItemStates[sceneName][itemId]; ok {
+		states.SetState(stateName, enabled)
+	}
+}
@@ -0,0 +1,52 @@
+package obs
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/andreykaipov/goobs"
+	"github.com/andreykaipov/goobs/api/events/subscriptions"
+	"github.com/andreykaipov/goobs/api/requests/general"
+	"github.com/normen/obs-mcu/config"
+)
+
+// ValidateLeds checks all led mappings in the config
+func ValidateLeds() []error {
+	errs := []error{}
+	for ledName, configVal := range config.Get().LedMap {
+		_, ledErrs := parseLedConfig(ledName, configVal)
+		for _, err := range ledErrs {
+			errs = append(errs, fmt.Errorf("[mcu_leds] %s: %v", ledName, err))
+		}
+	}
+	return errs
+}
+
+// ValidateChannels checks the channel rules in the config
+func ValidateChannels() []error {
+	_, errs := loadChannelRules()
+	return errs
+}
+
+// CheckHotkeys connects to OBS and checks if the hotkeys exist, hotkeys maps
+// to the button using it, returns the problems or an error if OBS can't be reached
+func CheckHotkeys(hotkeys map[string]string) ([]error, error) {
+	obs, err := goobs.New(config.Get().General.ObsHost,
+		goobs.WithPassword(config.Get().General.ObsPassword),
+		goobs.WithEventSubscriptions(subscriptions.None))
+	if err != nil {
+		return nil, err
+	}
+	defer obs.Disconnect()
+	list, err := obs.General.GetHotkeyList(&general.GetHotkeyListParams{})
+	if err != nil {
+		return nil, err
+	}
+	errs := []error{}
+	for key, buttonName := range hotkeys {
+		if !slices.Contains(list.Hotkeys, key) {
+			errs = append(errs, fmt.Errorf("[mcu_buttons] %s: unknown hotkey %q", buttonName, key))
+		}
+	}
+	return errs, nil
+}
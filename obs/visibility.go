@@ -0,0 +1,150 @@
+package obs
+
+import (
+	"log"
+	"slices"
+
+	"github.com/andreykaipov/goobs/api/requests/sceneitems"
+	"github.com/andreykaipov/goobs/api/requests/scenes"
+	"github.com/andreykaipov/goobs/api/typedefs"
+	"github.com/normen/obs-mcu/config"
+	"github.com/normen/obs-mcu/msg"
+)
+
+// the visibility modes of the channels, special inputs like
+// the desktop audio and mic are always visible
+const (
+	VisibleAll     = "all"
+	VisibleProgram = "program"
+	VisiblePreview = "preview"
+	VisibleScene   = "scene"
+)
+
+// the scenes and groups the visible channels were resolved from,
+// changes to their items update the visible channels
+var visibleScenes = map[string]bool{}
+
+// the visibility modes in the order the GlobalView button cycles through them
+var visibilityModes = []string{VisibleProgram, VisiblePreview, VisibleScene, VisibleAll}
+
+// switches to the next visibility mode and shows it on the lcd
+func (l *ChannelList) NextVisibilityMode() {
+	idx := slices.Index(visibilityModes, l.VisibilityMode)
+	l.VisibilityMode = visibilityModes[(idx+1)%len(visibilityModes)]
+	fromObs <- msg.NoticeMessage{
+		Text: "Channels: " + l.visibilityText(),
+	}
+	l.UpdateVisible()
+}
+
+// get the description of the visibility mode for the lcd
+func (l *ChannelList) visibilityText() string {
+	switch l.VisibilityMode {
+	case VisibleAll:
+		return "all inputs"
+	case VisiblePreview:
+		return "preview scene"
+	case VisibleScene:
+		return "scene " + config.Get().McuChannels.VisibilityScene
+	}
+	return "program scene"
+}
+
+// set the active state of an input in obs, only used in program mode
+func (l *ChannelList) SetActive(name string, active bool) {
+	if l.VisibilityMode == VisibleProgram || l.VisibilityMode == "" {
+		l.SetVisible(name, active || l.special[name])
+	}
+}
+
+// updates the visible channels when the program or preview scene changed
+func (l *ChannelList) ScenesChanged() {
+	if l.VisibilityMode != VisibleAll && l.VisibilityMode != VisibleScene {
+		l.UpdateVisible()
+	}
+}
+
+// updates the visible channels when an item of a scene or group
+// was enabled, disabled, added or removed
+func (l *ChannelList) SceneItemsChanged(sceneName string) {
+	if l.VisibilityMode != VisibleAll && visibleScenes[sceneName] {
+		l.UpdateVisible()
+	}
+}
+
+// get all visible channels from obs for the visibility mode and set their state locally
+func (l *ChannelList) UpdateVisible() {
+	var sources map[string]bool
+	switch l.VisibilityMode {
+	case VisibleAll:
+	case VisiblePreview:
+		// without studio mode the preview is the program scene
+		resp, err := client.Scenes.GetCurrentPreviewScene(&scenes.GetCurrentPreviewSceneParams{})
+		if err == nil {
+			sources = getSceneSources(resp.CurrentPreviewSceneName)
+		} else {
+			sources = getProgramSources()
+		}
+	case VisibleScene:
+		sources = getSceneSources(config.Get().McuChannels.VisibilityScene)
+	default:
+		sources = getProgramSources()
+	}
+	for name, channel := range l.inputs {
+		channel.Visible = l.VisibilityMode == VisibleAll || l.special[name] || sources[name]
+	}
+	l.sync()
+}
+
+// get the enabled sources of the program scene
+func getProgramSources() map[string]bool {
+	resp, err := client.Scenes.GetCurrentProgramScene()
+	if err != nil {
+		log.Print(err)
+		return map[string]bool{}
+	}
+	return getSceneSources(resp.CurrentProgramSceneName)
+}
+
+// get the enabled sources of a scene, including the sources
+// of all enabled scenes and groups nested in that scene
+func getSceneSources(sceneName string) map[string]bool {
+	visibleScenes = make(map[string]bool)
+	sources := make(map[string]bool)
+	addSceneSources(sceneName, false, sources)
+	return sources
+}
+
+// adds the enabled sources of a scene or group and resolves the nested
+// scenes and groups, each scene is only resolved once to prevent endless loops
+func addSceneSources(sceneName string, group bool, sources map[string]bool) {
+	if sceneName == "" || visibleScenes[sceneName] {
+		return
+	}
+	visibleScenes[sceneName] = true
+	var items []*typedefs.SceneItem
+	if group {
+		list, err := client.SceneItems.GetGroupSceneItemList(&sceneitems.GetGroupSceneItemListParams{SceneName: &sceneName})
+		if err != nil {
+			log.Print(err)
+			return
+		}
+		items = list.SceneItems
+	} else {
+		list, err := client.SceneItems.GetSceneItemList(&sceneitems.GetSceneItemListParams{SceneName: &sceneName})
+		if err != nil {
+			log.Print(err)
+			return
+		}
+		items = list.SceneItems
+	}
+	for _, item := range items {
+		if !item.SceneItemEnabled {
+			continue
+		}
+		sources[item.SourceName] = true
+		if item.SourceType == "OBS_SOURCE_TYPE_SCENE" {
+			addSceneSources(item.SourceName, item.IsGroup, sources)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/normen/obs-mcu/config"
+	"github.com/normen/obs-mcu/mcu"
+	"github.com/normen/obs-mcu/obs"
+)
+
+// checks the config file and prints all problems found,
+// returns false if there were errors
+func ValidateConfig() bool {
+	if err := config.ReadConfig(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return false
+	}
+	fmt.Printf("Checking config file %s\n", config.GetConfigFilePath())
+	errs := config.CheckKeys()
+	buttonErrs, hotkeys := mcu.ValidateButtons()
+	errs = append(errs, buttonErrs...)
+	errs = append(errs, obs.ValidateLeds()...)
+	errs = append(errs, obs.ValidateChannels()...)
+	if len(hotkeys) > 0 {
+		hotkeyErrs, err := obs.CheckHotkeys(hotkeys)
+		if err != nil {
+			fmt.Printf("Warning: could not check hotkeys, OBS not reachable (%v)\n", err)
+		}
+		errs = append(errs, hotkeyErrs...)
+	}
+	for _, err := range errs {
+		fmt.Printf("Error: %v\n", err)
+	}
+	if len(errs) > 0 {
+		fmt.Printf("%d errors found\n", len(errs))
+		return false
+	}
+	fmt.Println("No errors found")
+	return true
+}